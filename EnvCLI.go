@@ -4,10 +4,14 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
+
+	"github.com/Xanoor/EnvCLI/envfile"
 )
 
 var Reset = "\033[0m"
@@ -17,7 +21,7 @@ var Yellow = "\033[33m"
 var Gray = "\033[37m"
 
 // List of commands.
-var cmds = []string{"-help", "-get", "-create", "-rename", "-delete", "-read", "-add", "-remove", "-update", "-man", "-h", "-quit", "-q"}
+var cmds = []string{"-help", "-get", "-create", "-rename", "-delete", "-read", "-add", "-remove", "-update", "-set", "-export", "-import", "-diff", "-merge", "-validate", "-lint", "-run", "-history", "-restore", "-audit", "-gen", "-template", "-init", "-man", "-h", "-quit", "-q"}
 
 func isCommand(array []string, cmdName string) (int, int, bool) {
 	var index int
@@ -26,6 +30,16 @@ func isCommand(array []string, cmdName string) (int, int, bool) {
 
 	// Iterate through the array to find the command
 	for i, v := range array {
+		// Items after "--" are arguments, never commands
+		if v == endOfOptions {
+			if !hasBeenFound {
+				return 0, 0, false
+			} else if i == index {
+				return i + 1, len(array) - 1, true // "-var -- ARGS": every remaining item is an argument
+			}
+			return index, i - 1, true
+		}
+
 		if v == cmdName {
 			index = i + 1 // Set the starting index after the command
 			hasBeenFound = true
@@ -45,7 +59,49 @@ func isCommand(array []string, cmdName string) (int, int, bool) {
 	return 0, 0, false // Return false if the command was not found
 }
 
+// Exit codes of the one-shot mode.
+const (
+	exitSuccess  = 0
+	exitError    = 1 // I/O or unexpected error
+	exitUsage    = 2 // Invalid command or arguments
+	exitNotFound = 3 // File or variable not found
+	exitDeclined = 4 // Action cancelled by the user
+	exitInvalid  = 5 // The file doesn't pass the validation or the lint
+)
+
+// Exit code of the last command.
+var status = exitSuccess
+
+// Reader shared by every prompt, so piped input isn't lost between two reads.
+var stdin = bufio.NewReader(os.Stdin)
+
+// Read a line from the standard input, without leading/trailing whitespace.
+func readLine() (string, error) {
+	line, err := stdin.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Check if the file is a terminal rather than a pipe or a regular file.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 func main() {
+	// Run the command given on the command line, without the interactive prompt
+	if len(os.Args) > 1 {
+		if !isTerminal(os.Stdout) {
+			Reset, Red, Green, Yellow, Gray = "", "", "", "", "" // No color codes in scripts
+		}
+		if output := execute(os.Args[1:]); output != "" {
+			printOutput(output)
+		}
+		os.Exit(status)
+	}
+
 	fmt.Println(`
   ______             _____ _      _____ 
  |  ____|           / ____| |    |_   _|
@@ -58,62 +114,122 @@ func main() {
 
 	for {
 		fmt.Print(Red + "[EnvCLI] [" + time.Now().Format("15:04:05") + "] : " + Reset)
-		reader := bufio.NewReader(os.Stdin)
-		cmd, err := reader.ReadString('\n')
-		if err != nil {
+		cmd, err := readLine()
+		if err == io.EOF {
+			return
+		} else if err != nil {
 			fmt.Println("An error occurred!")
 			return
 		}
 
-		cmd = strings.TrimSpace(cmd)     // Remove any leading/trailing whitespace
-		input := strings.Split(cmd, " ") // Split input into command and arguments
-
-		// Check if the command is valid
-		if _, _, found := isCommand(cmds, input[0]); found {
-			if len(input) > 1 {
-				// Handle commands with additional arguments
-				switch input[0] {
-				case "-create":
-					fmt.Println(create(input))
-				case "-get":
-					fmt.Println(get(input))
-				case "-rename":
-					fmt.Println(rename(input))
-				case "-delete":
-					fmt.Println(delete(input))
-				case "-help", "-man", "-h":
-					fmt.Println(help(input))
-				case "-add":
-					fmt.Println(add(input))
-				case "-read":
-					fmt.Println(read(input))
-				case "-remove":
-					fmt.Println(remove(input))
-				case "-update":
-					fmt.Println(update(input))
-				default:
-					fmt.Println(Red + "Unknown command." + Reset)
-				}
-			} else {
-				// If only the command is provided
-				if input[0] == "-help" || input[0] == "-man" || input[0] == "-h" {
-					fmt.Println(help(input))
-				} else if input[0] == "-quit" || input[0] == "-q" {
-					break
-				} else {
-					fmt.Println(Red + "Expected one argument!" + Reset)
-				}
-			}
-		} else {
-			// If the command is invalid
-			fmt.Println(Red + "Invalid command!" + Yellow + "\nList of commands: -help\nDon't forget to add \"-\" in front of the command name!\n[EXAMPLE]: -help, -quit, -create, -delete" + Reset)
+		// Split input into command and arguments
+		input, err := tokenize(cmd)
+		if err != nil {
+			fmt.Println(Red + "Error: " + err.Error() + Reset)
+			continue
+		} else if len(input) == 0 {
+			continue
+		}
+
+		if len(input) == 1 && (input[0] == "-quit" || input[0] == "-q") {
+			break
+		}
+		printOutput(execute(input))
+	}
+}
+
+// Print the output of a command, to the standard error if the command failed
+// so scripts reading the standard output only get results.
+func printOutput(output string) {
+	if status != exitSuccess {
+		fmt.Fprintln(os.Stderr, output)
+	} else {
+		fmt.Println(output)
+	}
+}
+
+// Run the command and return its output.
+func execute(input []string) string {
+	status = exitSuccess
+	operation = strings.TrimPrefix(input[0], "-") // Recorded in the audit log
+
+	// Check if the command is valid
+	if _, _, found := isCommand(cmds, input[0]); !found {
+		status = exitUsage
+		return Red + "Invalid command!" + Yellow + "\nList of commands: -help\nDon't forget to add \"-\" in front of the command name!\n[EXAMPLE]: -help, -quit, -create, -delete" + Reset
+	}
+
+	if len(input) > 1 {
+		// Handle commands with additional arguments
+		switch input[0] {
+		case "-create":
+			return create(input)
+		case "-get":
+			return get(input)
+		case "-rename":
+			return rename(input)
+		case "-delete":
+			return delete(input)
+		case "-help", "-man", "-h":
+			return help(input)
+		case "-add":
+			return add(input)
+		case "-read":
+			return read(input)
+		case "-remove":
+			return remove(input)
+		case "-update":
+			return update(input)
+		case "-set":
+			return set(input)
+		case "-export":
+			return export(input)
+		case "-import":
+			return importVariables(input)
+		case "-diff":
+			return diff(input)
+		case "-merge":
+			return merge(input)
+		case "-validate":
+			return validate(input)
+		case "-lint":
+			return lint(input)
+		case "-run":
+			return run(input)
+		case "-history":
+			return history(input)
+		case "-restore":
+			return restore(input)
+		case "-audit":
+			return audit(input)
+		case "-gen":
+			return gen(input)
+		case "-template":
+			return template(input)
+		case "-init":
+			return initFile(input)
+		default:
+			status = exitUsage
+			return Red + "Unknown command." + Reset
 		}
 	}
+
+	// If only the command is provided
+	if input[0] == "-help" || input[0] == "-man" || input[0] == "-h" {
+		return help(input)
+	} else if input[0] == "-quit" || input[0] == "-q" {
+		return "" // Nothing to quit outside of the interactive prompt
+	} else if input[0] == "-audit" {
+		return audit(input) // Log of the current directory
+	}
+	status = exitUsage
+	return Red + "Expected one argument!" + Reset
 }
 
 // Adds the .env extension to the file name if it's not already present.
 func addExtension(fileName string) string {
-	if !strings.HasSuffix(fileName, ".env") {
+	// Layered files (.env.local, .env.staging, app.env.local...) keep their name
+	if !strings.HasSuffix(fileName, ".env") && !strings.Contains(filepath.Base(fileName), ".env.") {
 		return fileName + ".env"
 	}
 	return fileName
@@ -121,8 +237,7 @@ func addExtension(fileName string) string {
 
 func verify(sentence string) bool {
 	fmt.Println(Yellow + sentence + Reset)
-	var res string
-	fmt.Scanln(&res)
+	res, _ := readLine()
 
 	// Check the user's response
 	if res == "y" || res == "Y" {
@@ -135,57 +250,55 @@ func verify(sentence string) bool {
 	}
 }
 
-func writeData(fileName, variable, data string) bool {
-	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
-	if err != nil {
-		fmt.Println("Error opening file:", err)
-		return false
+func getVariable(match func(entry envfile.Entry) bool, description string, entries []envfile.Entry, expand bool, useEnv bool, reveal bool, raw bool) string {
+	var values map[string]string
+	var problems map[string][]string
+	var failed []string
+	if expand {
+		values, problems, failed = expandEntries(entries, useEnv)
 	}
-	defer f.Close() // Ensure the file is closed at the end of the function
 
-	_, err = fmt.Fprintln(f, variable+"="+data)
-	if err != nil {
-		fmt.Println("Error writing to file:", err)
-		return false
-	}
-
-	return true
-}
-
-func overwriteFile(filePath string, content []string) bool {
-	// Create or open the file for writing
-	file, err := os.Create(filePath)
-	if err != nil {
-		fmt.Println("Error opening file:", err)
-		return false
-	}
-	defer file.Close() // Ensure the file is closed after use
-
-	// Write the new content to the file
-	_, err = file.WriteString(strings.Join(content, "\n"))
-	if err != nil {
-		fmt.Println("Error writing to file:", err)
-		return false
-	}
-	return true
-}
-
-func getVariable(variable string, content string) string {
 	result := ""
+	warnings := ""
 	state := false
-	// Iterate through each line in the content
-	for _, line := range strings.Split(content, "\n") {
-		// Check if the line contains the specified variable/value
-		if strings.Contains(strings.ToUpper(line), variable) {
-			result += "-" + line + "\n" // Append the line to the result
+	// Iterate through each variable in the content
+	for _, entry := range entries {
+		if match(entry) {
+			if expand {
+				entry.Value = values[entry.Key]
+				label := Yellow + "Warning: "
+				if slices.Contains(failed, entry.Key) {
+					status = exitInvalid // A required variable is missing, or a cycle
+					label = Red + "Error: "
+				} else {
+					entry.Template = envfile.LiteralTemplate(entry.Value) // Shown expanded
+				}
+				for _, problem := range problems[entry.Key] {
+					warnings += label + entry.Key + ": " + problem + Reset + "\n"
+				}
+			}
+			if raw && isSecret(entry.Key) && !reveal {
+				// A masked value would be taken for the real one by scripts
+				status = exitUsage
+				return Red + entry.Key + " is a secret, add --reveal to print its value with --raw!" + Reset
+			} else if raw {
+				result += entry.Value + "\n" // Only the value, for scripts
+			} else {
+				result += "-" + displayEntry(entry, reveal) + "\n" // Append the variable to the result, secrets masked
+			}
 			state = true
 		}
 	}
 
-	if state {
+	// Warnings are kept out of the standard output read by scripts
+	fmt.Fprint(os.Stderr, warnings)
+	if state && raw {
+		return strings.TrimSuffix(result, "\n")
+	} else if state {
 		return Green + "Variable(s)/Value found!\n" + result + Reset
 	} else {
-		return Red + variable + " variable/value doesn't exist!" + Reset
+		status = exitNotFound
+		return Red + description + " variable/value doesn't exist!" + Reset
 	}
 }
 
@@ -206,58 +319,85 @@ func isFileValid(path string) bool {
 }
 
 func updateVar(index int, maxIndex int, fileName string, command []string, prompt bool) string {
-	// Iterate over the specified variable indices to update their values
+	// Retrieve the current variables from the file
+	doc, err := envfile.Load(fileName)
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+
+	schema, err := schemaFor(fileName)
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+	hidden, confirm := promptOptions(command)
+
+	// Check that every variable exists (and inline values are valid) before asking for values
 	for i := index; i <= maxIndex; i++ {
-		var newData []string
-		// Retrieve the current data from the file
-		data, err := getFileData(fileName)
-		if err != nil {
-			return Red + "Error occurred during operation!" + Reset
+		key, value, hasValue := splitAssignment(command[i])
+		if !envfile.ValidName(key) {
+			status = exitUsage
+			return invalidName(key)
 		}
-
-		// Check if the variable exists in the file
-		if !strings.Contains(data, command[i]+"=") {
-			return Red + command[i] + " wasn't found!" + Reset
+		if _, found := doc.Lookup(key); !found {
+			status = exitNotFound
+			return Red + key + " wasn't found!" + Reset
 		}
-
-		// Prompt the user for a new value for the variable
-		fmt.Println(Yellow + "Insert new value for variable " + command[i] + ":" + Reset)
-		reader := bufio.NewReader(os.Stdin)
-		value, err := reader.ReadString('\n')
-		if err != nil {
-			return Red + "An error occurred!" + Reset
+		if err := schema.check(key, value); hasValue && err != nil {
+			status = exitInvalid
+			return Red + "Invalid value: " + err.Error() + Reset
 		}
+	}
+
+	// Iterate over the specified variable indices to update their values
+	var updated []string
+	for i := index; i <= maxIndex; i++ {
+		// The new value is either given inline (KEY=VALUE) or prompted
+		key, value, hasValue := splitAssignment(command[i])
 
-		value = strings.TrimSpace(value) // Remove any extra whitespace
+		if !hasValue {
+			// Prompt the user for a new value for the variable
+			value, err = promptValue("Insert new value for variable "+key+":", key, schema, hidden, confirm)
+			if err != nil {
+				status = exitError
+				return Red + "An error occurred!" + Reset
+			}
+		}
 
-		var varToUpdate []string
-		// If prompting is enabled, confirm the value change with the user
+		// If prompting is enabled, confirm the value change with the user, without
+		// showing secrets or values typed with -hidden
 		if prompt {
-			if state := verify(Yellow + "Are you sure you want to change the value of variable " + command[i] + " to " + value + "? (y/n)" + Reset); !state {
+			shown := displayValue(key, value, false)
+			if hidden && !hasValue {
+				shown = maskValue(value)
+			}
+			if state := verify(Yellow + "Are you sure you want to change the value of variable " + key + " to " + shown + "? (y/n)" + Reset); !state {
+				status = exitDeclined
 				fmt.Println(Red + "Variable not updated!" + Reset)
 				continue // Skip to the next variable if the user declines
 			}
 		}
-		varToUpdate = append(varToUpdate, command[i])
 
-		// Split the current data into lines to find the variable to update
-		splittedData := strings.Split(data, "\n")
-		for l := 0; l < len(splittedData); l++ {
-			splittedVar := strings.Split(splittedData[l], "=")
-			// Check if the current line corresponds to the variable being updated
-			if splittedVar[0] == command[i] && slices.Contains(varToUpdate, command[i]) {
-				newData = append(newData, splittedVar[0]+"="+value) // Update the value
-			} else {
-				newData = append(newData, splittedData[l]) // Keep the existing line
-			}
+		// Replace every line defining the variable
+		if err := doc.Set(key, value); err != nil {
+			status = exitUsage
+			return Red + "Error: " + err.Error() + "!" + Reset
 		}
+		updated = append(updated, key)
+	}
 
-		// Attempt to overwrite the file with the updated data
-		if result := overwriteFile(fileName, newData); result {
-			fmt.Println(Green + command[i] + " successfully updated!" + Reset)
-		} else {
-			fmt.Println(Red + "Error when updating variable " + command[i] + "!" + Reset)
-		}
+	if len(updated) == 0 {
+		return ""
+	}
+
+	// Write every change at once
+	if result := saveDocument(fileName, doc); !result {
+		status = exitError
+		return Red + "Error when updating variable(s) " + strings.Join(updated, ", ") + "!" + Reset
+	}
+	for _, key := range updated {
+		fmt.Println(Green + key + " successfully updated!" + Reset)
 	}
 	return ""
 }
@@ -274,9 +414,11 @@ func update(command []string) string {
 			// Call the updateVar function to update the specified variables
 			return updateVar(index, maxIndex, command[1], command, prompt)
 		} else {
+			status = exitUsage
 			return Red + "Incorrect use of command -update!\n-help -update for more info!" + Reset
 		}
 	} else {
+		status = exitNotFound
 		return Red + command[1] + " not found!" + Reset
 	}
 }
@@ -287,16 +429,16 @@ func create(command []string) string {
 	if isFileValid(command[1]) {
 		// Ask the user if they want to overwrite the existing file
 		if !verify(Yellow + "File already exists! Do you want to overwrite it? (y/n)" + Reset) {
+			status = exitDeclined
 			return Red + "Action cancelled!" + Reset
 		}
 	}
 
-	// Create the new file
-	file, err := os.Create(command[1])
-	if err != nil {
+	// Create the new file (empty)
+	if created := overwriteFile(command[1], ""); !created {
+		status = exitError
 		return Red + "Error when opening the file." + Reset
 	}
-	defer file.Close() // Ensure the file is closed after we're done
 
 	// Check for the presence of the -var option to add variables immediately
 	index, maxIndex, found := isCommand(command, "-var")
@@ -304,31 +446,12 @@ func create(command []string) string {
 		fmt.Println(addVariable(index, maxIndex, command)) // Add variables if -var is present
 		return Green + "File and variable(s) created successfully!" + Reset
 	} else {
-		// If the -s option is not found, ask if the user wants to add variables
+		// If the -s option is not found, offer to fill the variables of the example of the project
 		if _, _, found := isCommand(command, "-s"); !found {
-			if response := verify(Green + "File created, do you want to add variable(s)? (y/n)"); response {
-				fmt.Println("Write the first variable name: (stop with \"q\" or \"quit\")")
-				for {
-					var varName string
-					fmt.Scanln(&varName)
-					if varName == "q" || varName == "quit" {
-						break // Exit the loop if the user wants to stop
-					}
-					fmt.Println(Yellow + "Value of variable " + varName + ":" + Reset)
-					var varValue string
-					fmt.Scanln(&varValue)
-					if varValue == "q" || varValue == "quit" {
-						break // Exit the loop if the user wants to stop
-					}
-					// Attempt to write the variable to the file
-					if hasWroteData := writeData(command[1], varName, varValue); hasWroteData {
-						fmt.Println(Green + "Variable " + varName + " added to env.\n" + Reset)
-					} else {
-						break // Exit the loop on failure to write data
-					}
-					fmt.Println("Write the next variable name: (stop with \"q\" or \"quit\")")
+			if example := exampleFile(command); isFileValid(example) {
+				if response := verify(Green + "File created, do you want to fill the variables of " + example + "? (y/n)"); response {
+					return fillFromExample(command[1], example, command)
 				}
-				return Green + "File and variable(s) created successfully!" + Reset
 			}
 		}
 		return Green + "File created successfully!" + Reset
@@ -336,20 +459,51 @@ func create(command []string) string {
 }
 
 func addVariable(index int, maxIndex int, command []string) string {
+	doc, err := envfile.LoadOrNew(command[1])
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+
+	schema, err := schemaFor(command[1])
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+	hidden, confirm := promptOptions(command)
+
 	// Loop through each variable from the specified index to the maximum index
 	for i := index; i <= maxIndex; i++ {
-		fmt.Println(Yellow + "Insert value for variable " + command[i] + ":" + Reset)
-		reader := bufio.NewReader(os.Stdin)
-		data, err := reader.ReadString('\n') // Read user input until newline
-		if err != nil {
-			return Red + "An error occurred while reading the input!" + Reset
+		// The value is either given inline (KEY=VALUE) or prompted
+		key, data, hasValue := splitAssignment(command[i])
+		if !envfile.ValidName(key) {
+			status = exitUsage
+			return invalidName(key)
 		}
-		data = strings.TrimSpace(data) // Remove any leading/trailing whitespace
-
-		// Attempt to write the variable data to the file
-		if hasWroteData := writeData(command[1], command[i], data); !hasWroteData {
-			return Red + "An error occurred when adding " + command[i] + " with value: " + data + Reset
+		if _, exists := doc.Lookup(key); exists {
+			status = exitUsage
+			return Red + key + " already exists in " + command[1] + ", use -update or -set to change it!" + Reset
 		}
+		if !hasValue {
+			data, err = promptValue("Insert value for variable "+key+":", key, schema, hidden, confirm)
+			if err != nil {
+				status = exitError
+				return Red + "An error occurred while reading the input!" + Reset
+			}
+		} else if err := schema.check(key, data); err != nil {
+			status = exitInvalid
+			return Red + "Invalid value: " + err.Error() + Reset
+		}
+		if err := doc.Append(key, data); err != nil {
+			status = exitUsage
+			return Red + "Error: " + err.Error() + "!" + Reset
+		}
+	}
+
+	// Write every variable at once
+	if hasWroteData := saveDocument(command[1], doc); !hasWroteData {
+		status = exitError
+		return Red + "An error occurred when adding the variable(s)!" + Reset
 	}
 	return Green + "\nVariable(s) added!" + Reset
 }
@@ -364,108 +518,288 @@ func add(command []string) string {
 			// Call the function to add variables to the file
 			return addVariable(index, maxIndex, command)
 		} else {
+			status = exitUsage
 			return Red + "Incorrect use of the -add command!\n-help -add for more info!" + Reset
 		}
 	} else {
+		status = exitNotFound
 		return Red + command[1] + " not found!" + Reset
 	}
 }
 
-func remove(command []string) string {
+func set(command []string) string {
 	command[1] = addExtension(command[1])
 
-	if isFileValid(command[1]) {
-		index, _, found := isCommand(command, "-var") // Check for the presence of the -var option
-		if found {
-			content, err := getFileData(command[1])
-			if err != nil {
-				return Red + "Error when reading file!" + Reset
-			}
+	if !isFileValid(command[1]) {
+		status = exitNotFound
+		return Red + command[1] + " not found!" + Reset
+	}
 
-			contentArray := strings.Split(content, "\n") // Split the file content into an array of lines
-			varToRemove := command[index:]               // Variables to remove start from the index of -var
-
-			// Iterate over the content array from the end to avoid index issues while removing
-			for i := len(contentArray) - 1; i >= 0; i-- {
-				v := contentArray[i]
-				if len(v) > 2 {
-					for _, z := range varToRemove {
-						if strings.Contains(v, z) { // Check if the line contains the variable to remove
-							// Remove the variable from the content array
-							if i == len(contentArray) {
-								contentArray = contentArray[:i]
-							} else {
-								contentArray = append(contentArray[:i], contentArray[i+1:]...)
-							}
-						}
-					}
-				}
-			}
+	schema, err := schemaFor(command[1])
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
 
-			if response := verify(Yellow + "Are you sure you want to remove these variable(s)? (y/n)" + Reset); response {
-				// Overwrite the file with the updated content
-				if hasOverwrote := overwriteFile(command[1], contentArray); hasOverwrote {
-					return Green + "\nVariable(s) removed!" + Reset
-				}
-			} else {
-				return Green + "Variable(s) not removed!" + Reset
+	// Collect the KEY=VALUE assignments, "-p" excepted
+	var keys, values []string
+	onlyArguments := false
+	for _, arg := range command[2:] {
+		if !onlyArguments && arg == endOfOptions {
+			onlyArguments = true
+			continue
+		} else if !onlyArguments && arg == "-p" {
+			continue
+		}
+
+		key, value, hasValue := splitAssignment(arg)
+		if !hasValue {
+			status = exitUsage
+			return Red + "Expected KEY=VALUE, got \"" + arg + "\"!\n-help -set for more info!" + Reset
+		} else if !envfile.ValidName(key) {
+			status = exitUsage
+			return invalidName(key)
+		}
+		if err := schema.check(key, value); err != nil {
+			status = exitInvalid
+			return Red + "Invalid value: " + err.Error() + Reset
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	if len(keys) == 0 {
+		status = exitUsage
+		return Red + "Incorrect use of the -set command!\n-help -set for more info!" + Reset
+	}
+
+	doc, err := envfile.Load(command[1])
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+
+	// Check for the presence of the -p option (prompt for confirmation)
+	_, _, prompt := isCommand(command, "-p")
+	changed := 0
+	for i, key := range keys {
+		if prompt {
+			if state := verify(Yellow + "Are you sure you want to set " + key + " to " + displayValue(key, values[i], false) + "? (y/n)" + Reset); !state {
+				status = exitDeclined
+				fmt.Println(Red + key + " not set!" + Reset)
+				continue // Skip to the next variable if the user declines
 			}
+		}
 
+		// Update the variable if it exists, add it otherwise
+		if _, exists := doc.Lookup(key); exists {
+			fmt.Println(Green + key + " updated." + Reset)
 		} else {
-			return Red + "Incorrect use of the -remove command!\n-help -remove for more info!" + Reset
+			fmt.Println(Green + key + " added." + Reset)
 		}
-	} else {
+		if err := doc.Set(key, values[i]); err != nil {
+			status = exitUsage
+			return Red + "Error: " + err.Error() + "!" + Reset
+		}
+		changed++
+	}
+
+	if changed == 0 {
+		return Yellow + "Nothing to change." + Reset
+	} else if !saveDocument(command[1], doc) {
+		status = exitError
+		return Red + "Error when writing " + command[1] + "!" + Reset
+	}
+	return Green + "Variable(s) saved!" + Reset
+}
+
+func remove(command []string) string {
+	command[1] = addExtension(command[1])
+
+	if !isFileValid(command[1]) {
+		status = exitNotFound
 		return Yellow + command[1] + " not found!" + Reset
 	}
-	return Red + "A problem occurred!" + Reset
+
+	// Variables to remove follow -var, unless a pattern option is used
+	index, _, found := isCommand(command, "-var")
+	var names []string
+	if found {
+		names = arguments(command, index)
+	}
+	match, description, err := entryMatcher(command, names)
+	if err != nil {
+		status = exitUsage
+		return Red + "Incorrect use of the -remove command: " + err.Error() + "!\n-help -remove for more info!" + Reset
+	}
+
+	doc, err := envfile.Load(command[1])
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+
+	// Show the exact lines which will be removed
+	preview := ""
+	for _, node := range doc.Nodes {
+		if node.Kind == envfile.EntryNode && match(node.Entry) {
+			for i, line := range node.Raw {
+				preview += fmt.Sprintf("%4d | %s\n", node.Entry.Line+i+1, strings.TrimSuffix(line, "\r"))
+			}
+		}
+	}
+	if preview == "" {
+		status = exitNotFound
+		return Red + "No variable matches " + description + "!" + Reset
+	}
+	fmt.Print(Gray + "Lines to remove:\n" + preview + Reset)
+
+	if response := verify(Yellow + "Are you sure you want to remove these variable(s)? (y/n)" + Reset); !response {
+		status = exitDeclined
+		return Green + "Variable(s) not removed!" + Reset
+	}
+
+	doc.Remove(match)
+	// Overwrite the file with the updated content
+	if hasOverwrote := saveDocument(command[1], doc); !hasOverwrote {
+		status = exitError
+		return Red + "A problem occurred!" + Reset
+	}
+	return Green + "\nVariable(s) removed!" + Reset
 }
 
 func get(command []string) string {
 	command[1] = addExtension(command[1])
 
-	fileContent, err := getFileData(command[1])
-	if err != nil {
+	// Read the file, or the layers of its profile
+	layers, err := loadLayers(command)
+	if os.IsNotExist(err) || os.IsPermission(err) {
+		status = exitNotFound
 		return Red + "Error: File " + command[1] + " doesn't exist or cannot be read!" + Reset
+	} else if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
 	}
 
-	// Check if a variable name is provided as a command argument
-	if len(command) >= 3 {
-		command[2] = strings.ToUpper(command[2])    // Convert variable name to uppercase
-		return getVariable(command[2], fileContent) // Retrieve the variable value
-	} else {
+	// Check if variable names are provided as command arguments (or after --explain)
+	names := arguments(command, 2)
+	index, _, explaining := isCommand(command, "--explain")
+	if explaining {
+		names = append(names, arguments(command, index)...)
+	}
+	if len(names) == 0 && !hasPatternOption(command) {
 		fmt.Println(Yellow + "Enter variable name:" + Reset)
-		var res string
-		fmt.Scanln(&res)                     // Read the variable name from user input
-		res = strings.ToUpper(res)           // Convert to uppercase
-		return getVariable(res, fileContent) // Retrieve the variable value
+		res, _ := readLine() // Read the variable name from user input
+		names = []string{res}
+	}
+
+	match, description, err := entryMatcher(command, names)
+	if err != nil {
+		status = exitUsage
+		return Red + "Incorrect use of the -get command: " + err.Error() + "!\n-help -get for more info!" + Reset
+	}
+	// Secret values are masked unless --reveal is given
+	_, _, reveal := isCommand(command, "--reveal")
+	if explaining {
+		return explain(layers, match, description, reveal)
 	}
+	// Check for the presence of the --expand option (and --env to use the process environment)
+	_, _, expand := isCommand(command, "--expand")
+	_, _, useEnv := isCommand(command, "--env")
+	_, _, raw := isCommand(command, "--raw")
+	// Only the effective definition of each variable is shown, the last layer winning
+	return getVariable(match, description, envfile.Effective(layerEntries(layers)), expand, useEnv, reveal, raw)
 }
 
 func read(command []string) string {
 	command[1] = addExtension(command[1])
 
-	// Attempt to get the content of the specified file
-	fileContent, err := getFileData(command[1])
-	if err != nil {
+	// Attempt to get the content of the specified file, or the layers of its profile
+	layers, err := loadLayers(command)
+	if os.IsNotExist(err) || os.IsPermission(err) {
+		status = exitNotFound
 		return Red + "Error: File " + command[1] + " doesn't exist or cannot be read!" + Reset
+	} else if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+
+	// Check for the presence of the --expand option (and --env to use the process environment)
+	_, _, expand := isCommand(command, "--expand")
+	_, _, useEnv := isCommand(command, "--env")
+	_, _, reveal := isCommand(command, "--reveal") // Secret values are masked otherwise
+	// Each definition is expanded, its references resolving to the effective values
+	var x *expander
+	if expand {
+		x = newExpander(layerEntries(layers), useEnv)
 	}
 
-	// Return the content of the file
-	return Green + "Here is the content of " + command[1] + ":\n" + Reset + fileContent + "\n"
+	// Return the content of the file, with its comments
+	result := ""
+	warnings := ""
+	for _, layer := range layers {
+		if len(layers) > 1 {
+			result += Yellow + "# " + layer.Path + Reset + "\n"
+		}
+		for _, node := range layer.Doc.Nodes {
+			switch node.Kind {
+			case envfile.EntryNode:
+				entry := node.Entry
+				if !expand && (reveal || !isSecret(entry.Key) || entry.Value == "") {
+					// Shown as written, with its quotes and inline comment
+					for _, line := range node.Raw {
+						result += strings.TrimSuffix(line, "\r") + "\n"
+					}
+					continue
+				}
+				if expand {
+					var problems []string
+					var expanded bool
+					entry.Value, problems, expanded = x.definition(entry)
+					label := Yellow + "Warning: "
+					if !expanded {
+						status = exitInvalid // A required variable is missing, or a cycle
+						label = Red + "Error: "
+					} else {
+						entry.Template = envfile.LiteralTemplate(entry.Value) // Shown expanded
+					}
+					for _, problem := range problems {
+						warnings += label + entry.Key + ": " + problem + Reset + "\n"
+					}
+				}
+				result += displayEntry(entry, reveal) + "\n"
+			case envfile.CommentNode:
+				result += Gray + strings.TrimSpace(node.Raw[0]) + Reset + "\n"
+			default:
+				result += "\n"
+			}
+		}
+	}
+	fmt.Fprint(os.Stderr, warnings)
+	return Green + "Here is the content of " + layerNames(layers) + ":\n" + Reset + result
 }
 
 func renameFile(oldName string, newName string) string {
 	newName = addExtension(newName)
 
 	if isFileValid(newName) {
+		status = exitError
 		return Red + "A file with the name \"" + newName + "\" already exists!" + Reset
 	}
 
+	unlock, err := envfile.Lock(oldName)
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+	defer unlock()
+
 	// Attempt to rename the old file to the new name
-	err := os.Rename(oldName, newName)
+	err = os.Rename(oldName, newName)
 	if err != nil {
+		status = exitError
 		return Red + "Error: Unable to rename " + oldName + " to " + newName + "!" + Reset
 	}
+	auditRename(oldName, newName)
 
 	return Green + oldName + " has been renamed to " + newName + Reset
 }
@@ -479,24 +813,40 @@ func rename(command []string) string {
 		} else {
 			// Prompt the user to enter a new file name
 			fmt.Println(Yellow + "Enter a new file name." + Reset)
-			var res string
-			fmt.Scanln(&res) // Read the new name from user input
+			res, _ := readLine() // Read the new name from user input
 			if len(res) > 0 {
 				return renameFile(command[1], res) // Rename the file if a valid name is given
 			} else {
+				status = exitUsage
 				return Red + "No name has been given!" + Reset
 			}
 		}
 	} else {
+		status = exitNotFound
 		return Yellow + command[1] + " not found!" + Reset
 	}
 }
 
 func deleteFile(fileName string) string {
-	err := os.Remove(fileName)
+	unlock, err := envfile.Lock(fileName)
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+	defer unlock()
+
+	// Keep the content so the file can be restored with -restore
+	if err := saveSnapshot(fileName, true); err != nil {
+		status = exitError
+		return Red + "Error: cannot keep the previous version: " + err.Error() + Reset
+	}
+	content, _ := getFileData(fileName)
+	err = os.Remove(fileName)
 	if err != nil {
+		status = exitError
 		return Red + "Error: " + fileName + " has not been deleted!" + Reset
 	}
+	auditChange(fileName, content, "")
 	return Green + fileName + " has been successfully deleted!" + Reset
 }
 
@@ -511,10 +861,12 @@ func delete(command []string) string {
 			if response := verify(Yellow + "Are you sure you want to delete " + command[1] + " (y/n)? " + Reset); response {
 				return deleteFile(command[1]) // Delete the file if confirmed
 			} else {
+				status = exitDeclined
 				return Green + "File not deleted!" + Reset
 			}
 		}
 	} else {
+		status = exitNotFound
 		return Red + command[1] + " not found!" + Reset
 	}
 }
@@ -529,7 +881,9 @@ func help(command []string) string {
 Usage: -create [FILE NAME] [OPTIONS]
 Options:
 	-var []  | List of default variables to add.
+	--from []| Example whose variables are asked (default: .env.example, see -help -init).
 	-s       | Skip variable(s) prompt.
+	-hidden  | Don't echo the values typed (automatic for secrets like *_KEY).
 	
 -> Create a .env file.
 			` + Reset)
@@ -552,43 +906,224 @@ Options:
 		case "read", "-read":
 			fmt.Println(Gray + `
 [HELP - READ COMMAND - EnvCLI]
-Usage: -read [FILE NAME]
+Usage: -read [FILE NAME] [OPTIONS]
+Options:
+	--expand    | Expand the ${VAR} references of the values.
+	--env       | With --expand, use the process environment for unknown variables.
+	-profile [] | Read FILE, FILE.local, FILE.[] and FILE.[].local (later files win).
+	--reveal    | Show the values of the secrets (*_KEY, *_SECRET, *PASSWORD*, *TOKEN*).
 
 -> Return the content of the .env file.
 			` + Reset)
 		case "get", "-get":
 			fmt.Println(Gray + `
 [HELP - GET COMMAND - EnvCLI]
-Usage: -get [FILE NAME] [VARIABLE(S)]
-
--> Return a list of occurrences of the given variable(s).
+Usage: -get [FILE NAME] [VARIABLE(S)] [OPTIONS]
+Options:
+	--glob []   | Select the variables matching glob pattern(s) (DB_*).
+	--regex []  | Select the variables matching regular expression(s).
+	--value []  | Select the variables whose value contains the text(s).
+	--expand    | Expand the ${VAR} references of the values.
+	--env       | With --expand, use the process environment for unknown variables.
+	-profile [] | Read FILE, FILE.local, FILE.[] and FILE.[].local (later files win).
+	--explain   | Show the file(s) defining the variable(s) and which value wins.
+	--reveal    | Show the values of the secrets (*_KEY, *_SECRET, *PASSWORD*, *TOKEN*).
+	--raw       | Only print the value(s), one per line (for scripts, secrets need --reveal).
+
+-> Return the given variable(s).
 			` + Reset)
 		case "remove", "-remove":
 			fmt.Println(Gray + `
 [HELP - REMOVE COMMAND - EnvCLI]
-Usage: -remove [FILE NAME] -var [VARIABLE(S)]
+Usage: -remove [FILE NAME] -var [VARIABLE(S)] [OPTIONS]
+Options:
+	--glob []   | Select the variables matching glob pattern(s) (DB_*).
+	--regex []  | Select the variables matching regular expression(s).
+	--value []  | Select the variables whose value contains the text(s).
 
 -> Remove variable(s) from the .env file.
 			` + Reset)
 		case "add", "-add":
 			fmt.Println(Gray + `
 [HELP - ADD COMMAND - EnvCLI]
-Usage: -add [FILE NAME] -var [VAR(S)]
+Usage: -add [FILE NAME] -var [VAR(S) or VAR=VALUE] [OPTIONS]
+Options:
+	-hidden  | Don't echo the values typed (automatic for secrets like *_KEY).
+	-confirm | Type the hidden values twice.
 
--> Add variable(s) to a .env file.
+-> Add variable(s) to a .env file (use -update or -set for existing ones).
+			` + Reset)
+		case "set", "-set":
+			fmt.Println(Gray + `
+[HELP - SET COMMAND - EnvCLI]
+Usage: -set [FILE NAME] [VAR=VALUE...] [OPTIONS]
+Options:
+	-p       | Create confirmation message for every variable.
+
+-> Update variable(s), adding the missing ones.
 			` + Reset)
 		case "update", "-update":
 			fmt.Println(Gray + `
 [HELP - UPDATE COMMAND - EnvCLI]
-Usage: -update [FILE NAME] -var [VARIABLE(S)] [OPTIONS]
+Usage: -update [FILE NAME] -var [VARIABLE(S) or VAR=VALUE] [OPTIONS]
 Options:
 	-p       | Create confirmation message for every variable.
+	-hidden  | Don't echo the values typed (automatic for secrets like *_KEY).
+	-confirm | Type the hidden values twice.
 	
 -> Update .env file variable values.
 			` + Reset)
+		case "export", "-export":
+			fmt.Println(Gray + `
+[HELP - EXPORT COMMAND - EnvCLI]
+Usage: -export [FILE NAME] [OPTIONS]
+Options:
+	--format []     | json, yaml, toml, sh (default), fish, powershell or docker.
+	--prefix []     | Only export the variables starting with the prefix(es).
+	--strip-prefix  | Remove the prefix from the exported names.
+	--expand        | Expand the ${VAR} references of the values.
+	-profile []     | Export the layers of the profile (see -help -get).
+
+-> Write the variables in another format (eval $(EnvCLI -export test)).
+			` + Reset)
+		case "import", "-import":
+			fmt.Println(Gray + `
+[HELP - IMPORT COMMAND - EnvCLI]
+Usage: -import [FILE NAME] --from [SOURCE] [OPTIONS]
+Options:
+	--format []       | json, yaml, sh, env, docker or properties (guessed from the extension).
+	--on-conflict []  | skip, overwrite or ask (default) for the variables already present.
+
+-> Import variables from another file, nested keys becoming PARENT_CHILD.
+			` + Reset)
+		case "diff", "-diff":
+			fmt.Println(Gray + `
+[HELP - DIFF COMMAND - EnvCLI]
+Usage: -diff [FILE NAME] [OTHER FILE NAME] [OPTIONS]
+Options:
+	--reveal     | Show the values instead of hiding them.
+	--format []  | unified (diff of the variables) or json.
+
+-> Show the variables added, removed and changed between two files.
+			` + Reset)
+		case "merge", "-merge":
+			fmt.Println(Gray + `
+[HELP - MERGE COMMAND - EnvCLI]
+Usage: -merge [FILE NAME] [SOURCE FILE(S)] [OPTIONS]
+Options:
+	--strategy []  | For the variables with different values: ours (keep),
+	               | theirs (replace), ask (default) or fail (abort).
+
+-> Merge the variables of the source file(s) into the file.
+			` + Reset)
+		case "validate", "-validate":
+			fmt.Println(Gray + `
+[HELP - VALIDATE COMMAND - EnvCLI]
+Usage: -validate [FILE NAME] [OPTIONS]
+Options:
+	--schema []  | Schema file (default: FILE.env.schema or .env.schema).
+
+-> Check the variables against a schema (one "KEY type [required]
+   [default=...] [enum=a,b] [pattern=REGEX]" line per variable, types:
+   string, int, bool, url, duration, port, email).
+   -add, -update and -set also check the values against the schema.
+			` + Reset)
+		case "lint", "-lint":
+			fmt.Println(Gray + `
+[HELP - LINT COMMAND - EnvCLI]
+Usage: -lint [FILE NAME] [OPTIONS]
+Options:
+	--fix    | Repair the problems which don't change any value.
+
+-> Find duplicate variables, invalid names, whitespace around "=",
+   unquoted values with spaces or "#", CRLF line endings and a
+   missing final newline. Exit code 5 if problems remain.
+			` + Reset)
+		case "run", "-run":
+			fmt.Println(Gray + `
+[HELP - RUN COMMAND - EnvCLI]
+Usage: -run [FILE NAME] [OPTIONS] -- [PROGRAM] [ARGUMENTS]
+Options:
+	--clean      | Only pass the variables of the file to the program.
+	--allow []   | With --clean, variables of the environment to pass
+	               anyway (names or glob patterns like LC_*).
+	-profile []  | Load the layers of the profile (see -help -get).
+	--expand     | Expand the ${VAR} references of the values, from the
+	               environment as well unless --clean is given.
+
+-> Run a program with the variables of the file, their values being passed
+   as written unless --expand is given.
+   Signals are forwarded to the program, and EnvCLI exits with its status.
+			` + Reset)
+		case "history", "-history", "restore", "-restore":
+			fmt.Println(Gray + `
+[HELP - HISTORY COMMAND - EnvCLI]
+Usage: -history [FILE NAME]
+
+-> List the previous versions of the file, kept in .envcli/history
+   before every change or deletion (the last 20), with the variables
+   changed (+ added, - removed, ~ changed).
+-------------------------------------
+[HELP - RESTORE COMMAND - EnvCLI]
+Usage: -restore [FILE NAME] [ID] [OPTIONS]
+Options:
+	-v    | Restore without confirmation.
+
+-> Restore a previous version of the file, even after -delete.
+			` + Reset)
+		case "audit", "-audit":
+			fmt.Println(Gray + `
+[HELP - AUDIT COMMAND - EnvCLI]
+Usage: -audit [FILE NAME] [OPTIONS]
+Options:
+	--key []    | Only show the changes of the variable(s).
+	--since []  | Only show the changes since a duration (24h) or a date (2006-01-02).
+
+-> Show who changed the files and when, from .envcli/audit.log (the log of
+   the current directory without file name). Values are recorded as
+   HMAC-SHA256 hashes keyed by .envcli/audit.key, and every record holds the
+   hash of the previous one, so a modified or removed record is reported
+   (exit code 5).
+			` + Reset)
+		case "gen", "-gen":
+			fmt.Println(Gray + `
+[HELP - GEN COMMAND - EnvCLI]
+Usage: -gen [FILE NAME] -var [VARIABLE(S)] [OPTIONS]
+Options:
+	--type []     | secret (default), uuid, ed25519 or rsa (key pairs, the
+	                public key being written to VARIABLE_PUBLIC).
+	--charset []  | base64url (default), hex, alnum or symbols.
+	--length []   | Number of characters (default 32), or bits of RSA keys (default 3072).
+
+-> Write random values (crypto/rand) to the file, without displaying them.
+			` + Reset)
+		case "template", "-template":
+			fmt.Println(Gray + `
+[HELP - TEMPLATE COMMAND - EnvCLI]
+Usage: -template [FILE NAME] [OPTIONS]
+Options:
+	--output []  | Example file (default: .env.example next to the file).
+	--check      | Fail (exit code 5) if the example and the file have different variables.
+
+-> Write an example of the file: its comments and variables without their
+   values (schema defaults and hints excepted), to commit instead of the file.
+			` + Reset)
+		case "init", "-init":
+			fmt.Println(Gray + `
+[HELP - INIT COMMAND - EnvCLI]
+Usage: -init [FILE NAME] [OPTIONS]
+Options:
+	--from []  | Example file (default: .env.example next to the file).
+	-hidden    | Don't echo the values typed (automatic for secrets like *_KEY).
+
+-> Ask the variables of the example which are missing from the file or
+   still hold a placeholder (empty, changeme, TODO...), with their comments
+   as help. Enter keeps the proposed value, "gen" generates a secret.
+			` + Reset)
 		default:
+			status = exitUsage
 			fmt.Println(Red + command[1] + " is an unknown command!")
-			fmt.Println(Yellow + "List of commands: -help, -create, -read, -get, -delete, -remove" + Reset)
+			fmt.Println(Yellow + "List of commands: -help, -create, -read, -get, -delete, -remove, -set, -export, -import, -diff, -merge, -validate, -lint, -run, -history, -restore, -audit, -gen, -template, -init" + Reset)
 		}
 	} else {
 		return Gray + `
@@ -596,14 +1131,18 @@ Options:
 Usage: -create [FILE NAME] [OPTIONS]
 Options:
 	-var []  | List of default variables to add.
+	--from []| Example whose variables are asked (default: .env.example, see -help -init).
 	-s       | Skip variable(s) prompt.
+	-hidden  | Don't echo the values typed (automatic for secrets like *_KEY).
 	
 -> Create a .env file.
 -------------------------------------
 [HELP - UPDATE COMMAND - EnvCLI]
-Usage: -update [FILE NAME] -var [VARIABLE(S)] [OPTIONS]
+Usage: -update [FILE NAME] -var [VARIABLE(S) or VAR=VALUE] [OPTIONS]
 Options:
 	-p       | Create confirmation message for every variable.
+	-hidden  | Don't echo the values typed (automatic for secrets like *_KEY).
+	-confirm | Type the hidden values twice.
 	
 -> Update .env file variable values.
 -------------------------------------
@@ -620,24 +1159,176 @@ Usage: -rename [FILE NAME] [NEW NAME]
 -> Rename a file.
 -------------------------------------
 [HELP - REMOVE COMMAND - EnvCLI]
-Usage: -remove [FILE NAME] -var [VARIABLE(S)]
+Usage: -remove [FILE NAME] -var [VARIABLE(S)] [OPTIONS]
+Options:
+	--glob []   | Select the variables matching glob pattern(s) (DB_*).
+	--regex []  | Select the variables matching regular expression(s).
+	--value []  | Select the variables whose value contains the text(s).
 
 -> Remove variable(s) from the .env file.
 -------------------------------------
 [HELP - GET COMMAND - EnvCLI]
-Usage: -get [FILE NAME] [VARIABLE(S)]
-
--> Return a list of occurrences of the given variable(s).
+Usage: -get [FILE NAME] [VARIABLE(S)] [OPTIONS]
+Options:
+	--glob []   | Select the variables matching glob pattern(s) (DB_*).
+	--regex []  | Select the variables matching regular expression(s).
+	--value []  | Select the variables whose value contains the text(s).
+	--expand    | Expand the ${VAR} references of the values.
+	--env       | With --expand, use the process environment for unknown variables.
+	-profile [] | Read FILE, FILE.local, FILE.[] and FILE.[].local (later files win).
+	--explain   | Show the file(s) defining the variable(s) and which value wins.
+	--reveal    | Show the values of the secrets (*_KEY, *_SECRET, *PASSWORD*, *TOKEN*).
+	--raw       | Only print the value(s), one per line (for scripts, secrets need --reveal).
+
+-> Return the given variable(s).
 -------------------------------------
 [HELP - READ COMMAND - EnvCLI]
-Usage: -read [FILE NAME]
+Usage: -read [FILE NAME] [OPTIONS]
+Options:
+	--expand    | Expand the ${VAR} references of the values.
+	--env       | With --expand, use the process environment for unknown variables.
+	-profile [] | Read FILE, FILE.local, FILE.[] and FILE.[].local (later files win).
+	--reveal    | Show the values of the secrets (*_KEY, *_SECRET, *PASSWORD*, *TOKEN*).
 
 -> Return the content of the .env file.
 -------------------------------------
 [HELP - ADD COMMAND - EnvCLI]
-Usage: -add [FILE NAME] -var [VAR(S)]
+Usage: -add [FILE NAME] -var [VAR(S) or VAR=VALUE] [OPTIONS]
+Options:
+	-hidden  | Don't echo the values typed (automatic for secrets like *_KEY).
+	-confirm | Type the hidden values twice.
+
+-> Add variable(s) to a .env file (use -update or -set for existing ones).
+-------------------------------------
+[HELP - SET COMMAND - EnvCLI]
+Usage: -set [FILE NAME] [VAR=VALUE...] [OPTIONS]
+Options:
+	-p       | Create confirmation message for every variable.
+
+-> Update variable(s), adding the missing ones.
+-------------------------------------
+[HELP - EXPORT COMMAND - EnvCLI]
+Usage: -export [FILE NAME] [OPTIONS]
+Options:
+	--format []     | json, yaml, toml, sh (default), fish, powershell or docker.
+	--prefix []     | Only export the variables starting with the prefix(es).
+	--strip-prefix  | Remove the prefix from the exported names.
+	--expand        | Expand the ${VAR} references of the values.
+	-profile []     | Export the layers of the profile (see -help -get).
+
+-> Write the variables in another format (eval $(EnvCLI -export test)).
+-------------------------------------
+[HELP - IMPORT COMMAND - EnvCLI]
+Usage: -import [FILE NAME] --from [SOURCE] [OPTIONS]
+Options:
+	--format []       | json, yaml, sh, env, docker or properties (guessed from the extension).
+	--on-conflict []  | skip, overwrite or ask (default) for the variables already present.
+
+-> Import variables from another file, nested keys becoming PARENT_CHILD.
+-------------------------------------
+[HELP - DIFF COMMAND - EnvCLI]
+Usage: -diff [FILE NAME] [OTHER FILE NAME] [OPTIONS]
+Options:
+	--reveal     | Show the values instead of hiding them.
+	--format []  | unified (diff of the variables) or json.
+
+-> Show the variables added, removed and changed between two files.
+-------------------------------------
+[HELP - MERGE COMMAND - EnvCLI]
+Usage: -merge [FILE NAME] [SOURCE FILE(S)] [OPTIONS]
+Options:
+	--strategy []  | For the variables with different values: ours (keep),
+	               | theirs (replace), ask (default) or fail (abort).
+
+-> Merge the variables of the source file(s) into the file.
+-------------------------------------
+[HELP - VALIDATE COMMAND - EnvCLI]
+Usage: -validate [FILE NAME] [OPTIONS]
+Options:
+	--schema []  | Schema file (default: FILE.env.schema or .env.schema).
+
+-> Check the variables against a schema (one "KEY type [required]
+   [default=...] [enum=a,b] [pattern=REGEX]" line per variable, types:
+   string, int, bool, url, duration, port, email).
+   -add, -update and -set also check the values against the schema.
+-------------------------------------
+[HELP - LINT COMMAND - EnvCLI]
+Usage: -lint [FILE NAME] [OPTIONS]
+Options:
+	--fix    | Repair the problems which don't change any value.
+
+-> Find duplicate variables, invalid names, whitespace around "=",
+   unquoted values with spaces or "#", CRLF line endings and a
+   missing final newline. Exit code 5 if problems remain.
+-------------------------------------
+[HELP - RUN COMMAND - EnvCLI]
+Usage: -run [FILE NAME] [OPTIONS] -- [PROGRAM] [ARGUMENTS]
+Options:
+	--clean      | Only pass the variables of the file to the program.
+	--allow []   | With --clean, variables of the environment to pass
+	               anyway (names or glob patterns like LC_*).
+	-profile []  | Load the layers of the profile (see -help -get).
+	--expand     | Expand the ${VAR} references of the values, from the
+	               environment as well unless --clean is given.
+
+-> Run a program with the variables of the file, their values being passed
+   as written unless --expand is given.
+   Signals are forwarded to the program, and EnvCLI exits with its status.
+-------------------------------------
+[HELP - HISTORY COMMAND - EnvCLI]
+Usage: -history [FILE NAME]
+
+-> List the previous versions of the file, kept in .envcli/history
+   before every change or deletion (the last 20), with the variables
+   changed (+ added, - removed, ~ changed).
+-------------------------------------
+[HELP - RESTORE COMMAND - EnvCLI]
+Usage: -restore [FILE NAME] [ID] [OPTIONS]
+Options:
+	-v    | Restore without confirmation.
+
+-> Restore a previous version of the file, even after -delete.
+-------------------------------------
+[HELP - AUDIT COMMAND - EnvCLI]
+Usage: -audit [FILE NAME] [OPTIONS]
+Options:
+	--key []    | Only show the changes of the variable(s).
+	--since []  | Only show the changes since a duration (24h) or a date (2006-01-02).
+
+-> Show who changed the files and when, from .envcli/audit.log (the log of
+   the current directory without file name). Values are recorded as
+   HMAC-SHA256 hashes keyed by .envcli/audit.key, and every record holds the
+   hash of the previous one, so a modified or removed record is reported
+   (exit code 5).
+-------------------------------------
+[HELP - GEN COMMAND - EnvCLI]
+Usage: -gen [FILE NAME] -var [VARIABLE(S)] [OPTIONS]
+Options:
+	--type []     | secret (default), uuid, ed25519 or rsa (key pairs, the
+	                public key being written to VARIABLE_PUBLIC).
+	--charset []  | base64url (default), hex, alnum or symbols.
+	--length []   | Number of characters (default 32), or bits of RSA keys (default 3072).
+
+-> Write random values (crypto/rand) to the file, without displaying them.
+-------------------------------------
+[HELP - TEMPLATE COMMAND - EnvCLI]
+Usage: -template [FILE NAME] [OPTIONS]
+Options:
+	--output []  | Example file (default: .env.example next to the file).
+	--check      | Fail (exit code 5) if the example and the file have different variables.
+
+-> Write an example of the file: its comments and variables without their
+   values (schema defaults and hints excepted), to commit instead of the file.
+-------------------------------------
+[HELP - INIT COMMAND - EnvCLI]
+Usage: -init [FILE NAME] [OPTIONS]
+Options:
+	--from []  | Example file (default: .env.example next to the file).
+	-hidden    | Don't echo the values typed (automatic for secrets like *_KEY).
 
--> Add variable(s) to a .env file.
+-> Ask the variables of the example which are missing from the file or
+   still hold a placeholder (empty, changeme, TODO...), with their comments
+   as help. Enter keeps the proposed value, "gen" generates a secret.
 					` + Reset
 	}
 	return ""
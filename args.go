@@ -0,0 +1,96 @@
+// Splitting of the prompt input into arguments.
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// Marker ending option parsing: the arguments after it are never options.
+const endOfOptions = "--"
+
+// Split a command line into arguments like a shell: single quotes keep their
+// content literal, double quotes group words and backslashes escape the next
+// character.
+func tokenize(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false // An argument has been started, possibly empty ("")
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			current.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case c == '"':
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				// Inside double quotes, backslashes only escape special characters
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte("\"\\$`", line[i+1]) >= 0 {
+					i++
+				}
+				current.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return nil, errors.New("unterminated double quote")
+			}
+			inArg = true
+		case c == '\\':
+			if i+1 >= len(line) {
+				return nil, errors.New("nothing to escape after \"\\\"")
+			}
+			i++
+			current.WriteByte(line[i])
+			inArg = true
+		default:
+			current.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// Split a KEY=VALUE argument. Return false if the argument has no value.
+func splitAssignment(arg string) (string, string, bool) {
+	key, value, found := strings.Cut(arg, "=")
+	if !found || key == "" {
+		return arg, "", false
+	}
+	return key, value, true
+}
+
+// Return the error shown for a variable name which cannot be written to a file.
+func invalidName(key string) string {
+	return Red + "\"" + key + "\" isn't a valid variable name ([A-Za-z_][A-Za-z0-9_]*)!" + Reset
+}
+
+// Return the arguments from command[index] up to the next option.
+func arguments(command []string, index int) []string {
+	for i := index; i < len(command); i++ {
+		if command[i] == endOfOptions {
+			return append(command[index:i:i], command[i+1:]...)
+		} else if strings.HasPrefix(command[i], "-") {
+			return command[index:i]
+		}
+	}
+	if index >= len(command) {
+		return nil
+	}
+	return command[index:]
+}
@@ -0,0 +1,310 @@
+// Audit log of the changes made to the files, kept in .envcli/audit.log next to
+// them. Each record holds the hash of the previous one, so editing or removing
+// a record breaks the chain.
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Name of the command being executed, recorded as the operation.
+var operation string
+
+// A change recorded in the audit log.
+type auditRecord struct {
+	Time      string            `json:"time"`
+	User      string            `json:"user"`
+	Host      string            `json:"host"`
+	File      string            `json:"file"`
+	Operation string            `json:"operation"`
+	Target    string            `json:"target,omitempty"` // New name of a renamed file
+	Added     []string          `json:"added,omitempty"`
+	Changed   []string          `json:"changed,omitempty"`
+	Removed   []string          `json:"removed,omitempty"`
+	Values    map[string]string `json:"values,omitempty"` // HMAC-SHA256 of the new values, never the values
+	Previous  string            `json:"previous"`         // Hash of the previous record
+	Hash      string            `json:"hash"`
+}
+
+// Return the audit log of the directory of the file.
+func auditPath(fileName string) string {
+	return filepath.Join(filepath.Dir(fileName), ".envcli", "audit.log")
+}
+
+// Return the hash of the record, computed without its own hash.
+func (record auditRecord) hash() string {
+	record.Hash = ""
+	data, _ := json.Marshal(record)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Return the key of the value hashes of the directory of the file, created on
+// first use. Without it, the values can't be guessed from their hashes.
+func auditKey(fileName string) ([]byte, error) {
+	path := filepath.Join(filepath.Dir(auditPath(fileName)), "audit.key")
+	if data, err := os.ReadFile(path); err == nil {
+		return hex.DecodeString(strings.TrimSpace(string(data)))
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	unlock, err := envfile.Lock(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Another session may have created the key while waiting for the lock
+	if data, err := os.ReadFile(path); err == nil {
+		return hex.DecodeString(strings.TrimSpace(string(data)))
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Return the hash of a value.
+func valueHash(key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Record the change of the file from before to after.
+func auditChange(fileName, before, after string) {
+	key, err := auditKey(fileName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, Yellow+"Warning: the change couldn't be recorded in the audit log: "+err.Error()+Reset)
+		return
+	}
+
+	record := auditRecord{File: filepath.Base(fileName)}
+	oldEntries, _ := envfile.ParseEntries(before)
+	newEntries, _ := envfile.ParseEntries(after)
+	for _, change := range compareEntries(oldEntries, newEntries) {
+		switch change.Kind {
+		case '+':
+			record.Added = append(record.Added, change.Key)
+		case '~':
+			record.Changed = append(record.Changed, change.Key)
+		case '-':
+			record.Removed = append(record.Removed, change.Key)
+			continue
+		default:
+			continue
+		}
+		if record.Values == nil {
+			record.Values = map[string]string{}
+		}
+		record.Values[change.Key] = valueHash(key, change.New)
+	}
+	recordAudit(fileName, record)
+}
+
+// Record the renaming of the file.
+func auditRename(oldName, newName string) {
+	recordAudit(oldName, auditRecord{File: filepath.Base(oldName), Target: filepath.Base(newName)})
+}
+
+// Complete the record and append it to the audit log of the file. A failure
+// is reported without undoing the change.
+func recordAudit(fileName string, record auditRecord) {
+	if err := appendAudit(auditPath(fileName), record); err != nil {
+		fmt.Fprintln(os.Stderr, Yellow+"Warning: the change couldn't be recorded in the audit log: "+err.Error()+Reset)
+	}
+}
+
+func appendAudit(path string, record auditRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	unlock, err := envfile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// A broken chain doesn't stop the recording: the record follows the last
+	// readable one, and -audit keeps reporting the break
+	records, _ := readAudit(path)
+	if len(records) > 0 {
+		record.Previous = records[len(records)-1].Hash
+	}
+
+	record.Time = time.Now().UTC().Format(time.RFC3339)
+	record.Operation = operation
+	record.Host, _ = os.Hostname()
+	record.User = os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		record.User = current.Username
+	}
+	record.Hash = record.hash()
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	// The last line may have been left half-written by a crashed session
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read the records of the audit log, checking the chain of hashes. Every
+// readable record is returned, with the error of the first break of the chain.
+func readAudit(path string) ([]auditRecord, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []auditRecord
+	var broken error
+	previous := ""
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var record auditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			if broken == nil {
+				broken = fmt.Errorf("%s:%d: invalid record", path, line)
+			}
+			continue
+		}
+		if (record.Previous != previous || record.Hash != record.hash()) && broken == nil {
+			broken = fmt.Errorf("%s:%d: the record has been modified, or a previous one removed", path, line)
+		}
+		records = append(records, record)
+		previous = record.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return records, err
+	}
+	return records, broken
+}
+
+// Parse the value of --since: a duration (24h) or a date (2006-01-02 or RFC 3339).
+func parseSince(value string) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-duration), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("expected a duration (24h) or a date (2006-01-02) after --since")
+}
+
+func audit(command []string) string {
+	// The file is optional: the log of the current directory is read otherwise
+	fileName := ""
+	path := auditPath("audit")
+	if len(command) > 1 && !strings.HasPrefix(command[1], "-") {
+		fileName = addExtension(command[1])
+		path = auditPath(fileName)
+	}
+
+	var keys []string
+	if index, _, found := isCommand(command, "--key"); found {
+		keys = arguments(command, index)
+	}
+	var since time.Time
+	if index, maxIndex, found := isCommand(command, "--since"); found {
+		var err error
+		if index != maxIndex {
+			err = errors.New("expected one value after --since")
+		} else {
+			since, err = parseSince(command[index])
+		}
+		if err != nil {
+			status = exitUsage
+			return Red + "Incorrect use of the -audit command: " + err.Error() + "!\n-help -audit for more info!" + Reset
+		}
+	}
+
+	// Records are shown even when the chain is broken, the break being reported after them
+	records, err := readAudit(path)
+	if len(records) == 0 && err == nil {
+		status = exitNotFound
+		return Red + "No change has been recorded in " + path + "!" + Reset
+	}
+
+	result := ""
+	for _, record := range records {
+		t, _ := time.Parse(time.RFC3339, record.Time)
+		if t.Before(since) || (fileName != "" && record.File != filepath.Base(fileName) && record.Target != filepath.Base(fileName)) {
+			continue
+		}
+		touched := slices.Concat(record.Added, record.Changed, record.Removed)
+		if len(keys) > 0 && !slices.ContainsFunc(keys, func(key string) bool { return slices.Contains(touched, key) }) {
+			continue
+		}
+
+		var changes []string
+		for _, key := range record.Added {
+			changes = append(changes, "+"+key+" ("+fmt.Sprintf("%.12s", record.Values[key])+")")
+		}
+		for _, key := range record.Changed {
+			changes = append(changes, "~"+key+" ("+fmt.Sprintf("%.12s", record.Values[key])+")")
+		}
+		for _, key := range record.Removed {
+			changes = append(changes, "-"+key)
+		}
+		if record.Target != "" {
+			changes = append(changes, "renamed to "+record.Target)
+		}
+		if len(changes) == 0 {
+			changes = append(changes, "no variable changed")
+		}
+		result += fmt.Sprintf("%s | %s@%s | %s | %s | %s\n", t.Local().Format("2006-01-02 15:04:05"), record.User, record.Host, record.Operation, record.File, strings.Join(changes, ", "))
+	}
+
+	if err != nil {
+		status = exitInvalid
+		return result + Red + "Error: " + err.Error() + Reset
+	} else if result == "" {
+		status = exitNotFound
+		return Red + "No recorded change matches!" + Reset
+	}
+	return Green + "Changes recorded in " + path + " (value hashes are HMAC-SHA256, keyed by audit.key):\n" + Reset + strings.TrimSuffix(result, "\n")
+}
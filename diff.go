@@ -0,0 +1,142 @@
+// Comparison of two .env files.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Difference of a variable between two files.
+type keyChange struct {
+	Key      string
+	Old, New string
+	Kind     byte // '+' added, '-' removed, '~' changed, ' ' unchanged
+}
+
+// Compare the effective variables of two files, in the order of the first file
+// followed by the variables only in the second one.
+func compareEntries(a, b []envfile.Entry) []keyChange {
+	var changes []keyChange
+	newValues := map[string]string{}
+	for _, entry := range envfile.Effective(b) {
+		newValues[entry.Key] = entry.Value
+	}
+
+	oldKeys := map[string]bool{}
+	for _, entry := range envfile.Effective(a) {
+		oldKeys[entry.Key] = true
+		value, found := newValues[entry.Key]
+		switch {
+		case !found:
+			changes = append(changes, keyChange{Key: entry.Key, Old: entry.Value, Kind: '-'})
+		case value != entry.Value:
+			changes = append(changes, keyChange{Key: entry.Key, Old: entry.Value, New: value, Kind: '~'})
+		default:
+			changes = append(changes, keyChange{Key: entry.Key, Old: value, New: value, Kind: ' '})
+		}
+	}
+	for _, entry := range envfile.Effective(b) {
+		if !oldKeys[entry.Key] {
+			changes = append(changes, keyChange{Key: entry.Key, New: entry.Value, Kind: '+'})
+		}
+	}
+	return changes
+}
+
+func diff(command []string) string {
+	if len(command) < 3 {
+		status = exitUsage
+		return Red + "Incorrect use of the -diff command!\n-help -diff for more info!" + Reset
+	}
+	command[1] = addExtension(command[1])
+	command[2] = addExtension(command[2])
+
+	var files [2][]envfile.Entry
+	for i, fileName := range command[1:3] {
+		doc, err := envfile.Load(fileName)
+		if err != nil {
+			status = exitNotFound
+			return Red + "Error: File " + fileName + " doesn't exist or cannot be read!" + Reset
+		}
+		files[i] = doc.Entries()
+	}
+	changes := compareEntries(files[0], files[1])
+
+	// Values are hidden unless --reveal is given, secrets or not
+	_, _, reveal := isCommand(command, "--reveal")
+	show := func(value string) string {
+		if reveal {
+			return value
+		}
+		return maskValue(value)
+	}
+
+	format := "text"
+	if index, maxIndex, found := isCommand(command, "--format"); found {
+		if index != maxIndex || (command[index] != "unified" && command[index] != "json") {
+			status = exitUsage
+			return Red + "Expected --format unified or json!" + Reset
+		}
+		format = command[index]
+	}
+
+	switch format {
+	case "json":
+		result := map[string]any{"added": map[string]string{}, "removed": map[string]string{}, "changed": map[string]map[string]string{}}
+		for _, change := range changes {
+			switch change.Kind {
+			case '+':
+				result["added"].(map[string]string)[change.Key] = show(change.New)
+			case '-':
+				result["removed"].(map[string]string)[change.Key] = show(change.Old)
+			case '~':
+				result["changed"].(map[string]map[string]string)[change.Key] = map[string]string{"old": show(change.Old), "new": show(change.New)}
+			}
+		}
+		data, _ := json.MarshalIndent(result, "", "  ")
+		return string(data)
+
+	case "unified":
+		// Unified diff of the files written as one KEY=VALUE line per variable
+		var lines []string
+		oldCount, newCount := 0, 0
+		for _, change := range changes {
+			if change.Kind != '+' {
+				oldCount++
+			}
+			if change.Kind != '-' {
+				newCount++
+			}
+			switch change.Kind {
+			case ' ':
+				lines = append(lines, " "+envfile.FormatEntry(envfile.Entry{Key: change.Key, Value: show(change.Old)}))
+			case '-', '~':
+				lines = append(lines, "-"+envfile.FormatEntry(envfile.Entry{Key: change.Key, Value: show(change.Old)}))
+			}
+			if change.Kind == '+' || change.Kind == '~' {
+				lines = append(lines, "+"+envfile.FormatEntry(envfile.Entry{Key: change.Key, Value: show(change.New)}))
+			}
+		}
+		header := fmt.Sprintf("--- %s\n+++ %s\n@@ -%d,%d +%d,%d @@", command[1], command[2], min(oldCount, 1), oldCount, min(newCount, 1), newCount)
+		return header + "\n" + strings.Join(lines, "\n")
+	}
+
+	result := ""
+	for _, change := range changes {
+		switch change.Kind {
+		case '+':
+			result += Green + "+ " + change.Key + " = " + show(change.New) + " (only in " + command[2] + ")" + Reset + "\n"
+		case '-':
+			result += Red + "- " + change.Key + " = " + show(change.Old) + " (only in " + command[1] + ")" + Reset + "\n"
+		case '~':
+			result += Yellow + "~ " + change.Key + ": " + show(change.Old) + " -> " + show(change.New) + Reset + "\n"
+		}
+	}
+	if result == "" {
+		return Green + command[1] + " and " + command[2] + " have the same variables." + Reset
+	}
+	return Green + "Differences between " + command[1] + " and " + command[2] + ":\n" + Reset + strings.TrimSuffix(result, "\n")
+}
@@ -0,0 +1,318 @@
+// Package envfile reads and edits .env files.
+//
+// A Document keeps the comments, blank lines and ordering of the file, so
+// editing one variable only changes the lines of that variable:
+//
+//	doc, err := envfile.Load(".env")
+//	if err != nil {
+//		return err
+//	}
+//	if err := doc.Set("PORT", "8080"); err != nil {
+//		return err
+//	}
+//	if err := doc.Unset("DEBUG"); err != nil && !errors.Is(err, envfile.ErrNotFound) {
+//		return err
+//	}
+//	return envfile.Save(".env", doc)
+package envfile
+
+import (
+	"os"
+	"strings"
+)
+
+// NodeKind is the kind of a line of a document.
+type NodeKind int
+
+// Kinds of document nodes.
+const (
+	BlankNode NodeKind = iota
+	CommentNode
+	EntryNode
+)
+
+// Node is a blank line, a comment or a variable of a document.
+type Node struct {
+	Kind  NodeKind
+	Raw   []string // Original lines, written back unchanged unless the node is edited
+	Entry Entry
+}
+
+// Document is a .env file keeping its comments, blank lines and ordering.
+type Document struct {
+	Nodes           []Node
+	TrailingNewline bool
+	CRLF            bool // The file uses Windows line endings
+
+	original string // Content of the file when it was read
+	tracked  bool   // The document has been read from a file
+}
+
+// Parse parses the content of a .env file into a document.
+// Errors are of type *ParseError.
+func Parse(content string) (*Document, error) {
+	doc := &Document{CRLF: strings.Contains(content, "\r\n")}
+	if content == "" {
+		return doc, nil
+	}
+	content, doc.TrailingNewline = strings.CutSuffix(content, "\n")
+	lines := strings.Split(content, "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(strings.TrimSuffix(lines[i], "\r"))
+		switch {
+		case line == "":
+			doc.Nodes = append(doc.Nodes, Node{Kind: BlankNode, Raw: lines[i : i+1]})
+		case strings.HasPrefix(line, "#"):
+			doc.Nodes = append(doc.Nodes, Node{Kind: CommentNode, Raw: lines[i : i+1]})
+		default:
+			entry, err := parseEntry(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			doc.Nodes = append(doc.Nodes, Node{Kind: EntryNode, Raw: lines[i : entry.EndLine+1], Entry: entry})
+			i = entry.EndLine // Continue after the last line of a multiline value
+		}
+	}
+	return doc, nil
+}
+
+// ParseEntries parses the content of a .env file into its list of variables.
+func ParseEntries(content string) ([]Entry, error) {
+	doc, err := Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Entries(), nil
+}
+
+// Load reads and parses a .env file.
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	doc.original, doc.tracked = string(data), true
+	return doc, nil
+}
+
+// LoadOrNew loads the file, or returns an empty document if it doesn't exist yet.
+func LoadOrNew(path string) (*Document, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return &Document{tracked: true}, nil
+	}
+	return Load(path)
+}
+
+// Save writes the document to the file, unless another session changed the
+// file since the document was read (*ConflictError).
+func Save(path string, doc *Document) error {
+	return SaveFunc(path, doc, nil)
+}
+
+// SaveFunc is like Save, and calls beforeWrite (if not nil) once the file is
+// locked and checked, just before writing it. An error of beforeWrite cancels
+// the write.
+func SaveFunc(path string, doc *Document, beforeWrite func() error) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if doc.tracked {
+		current, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if string(current) != doc.original {
+			return &ConflictError{Path: path, Original: doc.original, Current: string(current)}
+		}
+	}
+
+	if beforeWrite != nil {
+		if err := beforeWrite(); err != nil {
+			return err
+		}
+	}
+	content := doc.String()
+	if err := WriteFile(path, []byte(content)); err != nil {
+		return err
+	}
+	doc.original, doc.tracked = content, true
+	return nil
+}
+
+// Original returns the content of the file when the document was read or last
+// saved.
+func (doc *Document) Original() string {
+	return doc.original
+}
+
+// String returns the content of the document as written in the file.
+func (doc *Document) String() string {
+	var lines []string
+	for _, node := range doc.Nodes {
+		lines = append(lines, node.Raw...)
+	}
+	content := strings.Join(lines, "\n")
+	if doc.TrailingNewline && len(lines) > 0 {
+		content += "\n"
+	}
+	return content
+}
+
+// Entries returns the variables of the document in order, including the
+// repeated definitions.
+func (doc *Document) Entries() []Entry {
+	var entries []Entry
+	for _, node := range doc.Nodes {
+		if node.Kind == EntryNode {
+			entries = append(entries, node.Entry)
+		}
+	}
+	return entries
+}
+
+// Effective returns one entry per variable, with its effective value (the last
+// definition wins), in the order of the first definitions.
+func Effective(entries []Entry) []Entry {
+	var result []Entry
+	index := map[string]int{}
+	for _, entry := range entries {
+		if i, found := index[entry.Key]; found {
+			result[i].Value, result[i].Template = entry.Value, entry.Template
+			continue
+		}
+		index[entry.Key] = len(result)
+		result = append(result, entry)
+	}
+	return result
+}
+
+// Keys returns the names of the variables, in the order of their first
+// definitions.
+func (doc *Document) Keys() []string {
+	var keys []string
+	for _, entry := range Effective(doc.Entries()) {
+		keys = append(keys, entry.Key)
+	}
+	return keys
+}
+
+// Lookup returns the effective definition of the variable (the last one wins).
+func (doc *Document) Lookup(key string) (Entry, bool) {
+	for i := len(doc.Nodes) - 1; i >= 0; i-- {
+		if node := doc.Nodes[i]; node.Kind == EntryNode && node.Entry.Key == key {
+			return node.Entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Get returns the effective value of the variable, or a *KeyError if it
+// doesn't exist. References to other variables are not expanded.
+func (doc *Document) Get(key string) (string, error) {
+	entry, found := doc.Lookup(key)
+	if !found {
+		return "", &KeyError{Key: key}
+	}
+	return entry.Value, nil
+}
+
+// Return the lines of an edited entry.
+func (doc *Document) entryLines(entry Entry) []string {
+	line := FormatEntry(entry)
+	if doc.CRLF {
+		line += "\r"
+	}
+	return []string{line}
+}
+
+// Set changes the value of every definition of the variable, keeping their
+// inline comments, or adds it at the end of the document if it doesn't exist.
+// Values are written quoted, never expanded. It returns a *NameError if the name isn't valid.
+func (doc *Document) Set(key, value string) error {
+	return doc.SetEntry(Entry{Key: key, Value: value})
+}
+
+// SetEntry is like Set, but the value is written as the Template of the entry,
+// so its references are kept (an empty Template is the literal value). Only
+// the key, value and template of the entry are used.
+func (doc *Document) SetEntry(entry Entry) error {
+	if !ValidName(entry.Key) {
+		return &NameError{Key: entry.Key}
+	}
+	if entry.Template == "" {
+		entry.Template = LiteralTemplate(entry.Value)
+	}
+	found := false
+	for i, node := range doc.Nodes {
+		if node.Kind == EntryNode && node.Entry.Key == entry.Key {
+			node.Entry.Value = entry.Value
+			node.Entry.Template = entry.Template
+			node.Raw = doc.entryLines(node.Entry)
+			doc.Nodes[i] = node
+			found = true
+		}
+	}
+	if !found {
+		return doc.AppendEntry(Entry{Key: entry.Key, Value: entry.Value, Template: entry.Template})
+	}
+	return nil
+}
+
+// Append adds a variable at the end of the document, even if it is already
+// defined. It returns a *NameError if the name isn't valid.
+func (doc *Document) Append(key, value string) error {
+	return doc.AppendEntry(Entry{Key: key, Value: value})
+}
+
+// AppendEntry is like Append, but the entry is written with its export prefix,
+// its inline comment and its Template (an empty Template is the literal value).
+func (doc *Document) AppendEntry(entry Entry) error {
+	if !ValidName(entry.Key) {
+		return &NameError{Key: entry.Key}
+	}
+	if entry.Template == "" {
+		entry.Template = LiteralTemplate(entry.Value)
+	}
+	entry.Line, entry.EndLine = 0, 0
+	if len(doc.Nodes) == 0 {
+		doc.TrailingNewline = true // New files end with a newline
+	}
+	doc.Nodes = append(doc.Nodes, Node{Kind: EntryNode, Raw: doc.entryLines(entry), Entry: entry})
+	return nil
+}
+
+// Remove removes the variables accepted by match, keeping the surrounding
+// comments. It returns the removed variables.
+func (doc *Document) Remove(match func(entry Entry) bool) []Entry {
+	var removed []Entry
+	nodes := doc.Nodes[:0]
+	for _, node := range doc.Nodes {
+		if node.Kind == EntryNode && match(node.Entry) {
+			removed = append(removed, node.Entry)
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	doc.Nodes = nodes
+	return removed
+}
+
+// Unset removes every definition of the variable, or returns a *KeyError if it
+// doesn't exist.
+func (doc *Document) Unset(key string) error {
+	removed := doc.Remove(func(entry Entry) bool {
+		return entry.Key == key
+	})
+	if len(removed) == 0 {
+		return &KeyError{Key: key}
+	}
+	return nil
+}
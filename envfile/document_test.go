@@ -0,0 +1,327 @@
+package envfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Content of a file using every kind of line.
+const sample = `# Database
+export DB_HOST=localhost # the host
+DB_USER='admin'
+
+DB_PASSWORD="multi
+line"
+  INDENTED = value
+DB_HOST=override
+`
+
+func TestParseKeepsContent(t *testing.T) {
+	contents := []string{
+		"",
+		"\n",
+		sample,
+		"A=1",
+		"A=1\r\nB='2'\r\n# comment\r\n",
+		"A=\"x\r\ny\"\r\n",
+		"\n\n# only comments\n",
+	}
+	for _, content := range contents {
+		doc, err := Parse(content)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", content, err)
+		}
+		if got := doc.String(); got != content {
+			t.Errorf("Parse(%q).String() = %q", content, got)
+		}
+	}
+}
+
+func TestKeysAndGet(t *testing.T) {
+	doc, err := Parse(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys, want := doc.Keys(), []string{"DB_HOST", "DB_USER", "DB_PASSWORD", "INDENTED"}; !slices.Equal(keys, want) {
+		t.Errorf("Keys() = %q, want %q", keys, want)
+	}
+
+	tests := map[string]string{
+		"DB_HOST":     "override", // The last definition wins
+		"DB_USER":     "admin",
+		"DB_PASSWORD": "multi\nline",
+		"INDENTED":    "value",
+	}
+	for key, want := range tests {
+		if got, err := doc.Get(key); err != nil || got != want {
+			t.Errorf("Get(%q) = %q, %v, want %q", key, got, err, want)
+		}
+	}
+
+	_, err = doc.Get("MISSING")
+	var keyErr *KeyError
+	if !errors.As(err, &keyErr) || keyErr.Key != "MISSING" || !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(MISSING) error = %v, want a *KeyError matching ErrNotFound", err)
+	}
+}
+
+func TestEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		edit    func(doc *Document) error
+		want    string
+	}{
+		{
+			"set keeps the other lines",
+			sample,
+			func(doc *Document) error { return doc.Set("DB_USER", "bob") },
+			"# Database\nexport DB_HOST=localhost # the host\nDB_USER=bob\n\nDB_PASSWORD=\"multi\nline\"\n  INDENTED = value\nDB_HOST=override\n",
+		},
+		{
+			"set every definition, keeping export and comments",
+			sample,
+			func(doc *Document) error { return doc.Set("DB_HOST", "db") },
+			"# Database\nexport DB_HOST=db # the host\nDB_USER='admin'\n\nDB_PASSWORD=\"multi\nline\"\n  INDENTED = value\nDB_HOST=db\n",
+		},
+		{
+			"set quotes the value",
+			"A=1 # count\nB=2\n",
+			func(doc *Document) error { return doc.Set("A", "x y") },
+			"A='x y' # count\nB=2\n",
+		},
+		{
+			"set a multiline value",
+			sample,
+			func(doc *Document) error { return doc.Set("DB_PASSWORD", "secret") },
+			"# Database\nexport DB_HOST=localhost # the host\nDB_USER='admin'\n\nDB_PASSWORD=secret\n  INDENTED = value\nDB_HOST=override\n",
+		},
+		{
+			"set adds a missing variable",
+			"A=1\n",
+			func(doc *Document) error { return doc.Set("B", "2") },
+			"A=1\nB=2\n",
+		},
+		{
+			"set keeps CRLF line endings",
+			"A=1\r\nB=2\r\n",
+			func(doc *Document) error { return doc.Set("A", "3") },
+			"A=3\r\nB=2\r\n",
+		},
+		{
+			"set an entry keeps its references",
+			"A=1 # count\nB=2\n",
+			func(doc *Document) error {
+				return doc.SetEntry(Entry{Key: "A", Value: "http://${HOST}/x", Template: "http://${HOST}/x"})
+			},
+			"A=http://${HOST}/x # count\nB=2\n",
+		},
+		{
+			"append an entry as written",
+			"A=1\n",
+			func(doc *Document) error {
+				return doc.AppendEntry(Entry{Key: "B", Value: "${A} $x", Template: "${A} $$x", Export: true, Comment: "# note"})
+			},
+			"A=1\nexport B=\"${A} \\$x\" # note\n",
+		},
+		{
+			"append to an empty document",
+			"",
+			func(doc *Document) error { return doc.Append("A", "1") },
+			"A=1\n",
+		},
+		{
+			"unset every definition",
+			sample,
+			func(doc *Document) error { return doc.Unset("DB_HOST") },
+			"# Database\nDB_USER='admin'\n\nDB_PASSWORD=\"multi\nline\"\n  INDENTED = value\n",
+		},
+		{
+			"unset a multiline value",
+			sample,
+			func(doc *Document) error { return doc.Unset("DB_PASSWORD") },
+			"# Database\nexport DB_HOST=localhost # the host\nDB_USER='admin'\n\n  INDENTED = value\nDB_HOST=override\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.edit(doc); err != nil {
+				t.Fatal(err)
+			}
+			if got := doc.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if _, err := Parse(doc.String()); err != nil {
+				t.Errorf("the edited document cannot be read back: %v", err)
+			}
+		})
+	}
+}
+
+func TestEditErrors(t *testing.T) {
+	doc, err := Parse("A=1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"X\nEVIL", "K=V", "", "-X", "A B"} {
+		var nameErr *NameError
+		if err := doc.Set(key, "1"); !errors.As(err, &nameErr) {
+			t.Errorf("Set(%q) = %v, want a *NameError", key, err)
+		}
+		if err := doc.Append(key, "1"); !errors.As(err, &nameErr) {
+			t.Errorf("Append(%q) = %v, want a *NameError", key, err)
+		}
+		if err := doc.SetEntry(Entry{Key: key, Value: "${A}", Template: "${A}"}); !errors.As(err, &nameErr) {
+			t.Errorf("SetEntry(%q) = %v, want a *NameError", key, err)
+		}
+	}
+	if err := doc.Unset("MISSING"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Unset(MISSING) = %v, want ErrNotFound", err)
+	}
+	if got := doc.String(); got != "A=1\n" {
+		t.Errorf("failed edits changed the document: %q", got)
+	}
+}
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(sample), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Set("DB_USER", "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, doc); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != doc.String() || doc.Original() != doc.String() {
+		t.Errorf("Save wrote %q, want %q", data, doc.String())
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Save changed the mode of the file: %v", info.Mode())
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("the lock file is left after Save: %v", err)
+	}
+
+	// The document can be saved again after its own changes
+	if err := doc.Set("DB_USER", "carol"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, doc); err != nil {
+		t.Fatalf("second Save: %v", err)
+	}
+}
+
+func TestSaveConflict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("A=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another session changes the file after it has been read
+	if err := os.WriteFile(path, []byte("A=2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Set("B", "3"); err != nil {
+		t.Fatal(err)
+	}
+	err = Save(path, doc)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Save = %v, want a *ConflictError", err)
+	}
+	if conflict.Original != "A=1\n" || conflict.Current != "A=2\n" {
+		t.Errorf("ConflictError = %+v", conflict)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "A=2\n" {
+		t.Errorf("the file has been written despite the conflict: %q", data)
+	}
+
+	// A new file must not exist when the document is saved
+	newPath := filepath.Join(t.TempDir(), "new.env")
+	newDoc, err := LoadOrNew(newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newPath, []byte("A=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(newPath, newDoc); !errors.As(err, &conflict) {
+		t.Errorf("Save of a file created meanwhile = %v, want a *ConflictError", err)
+	}
+}
+
+func TestSaveFunc(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	doc, err := LoadOrNew(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Set("A", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("no snapshot")
+	if err := SaveFunc(path, doc, func() error { return failure }); err != failure {
+		t.Errorf("SaveFunc = %v, want the error of beforeWrite", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the file has been written despite the error of beforeWrite")
+	}
+
+	called := false
+	if err := SaveFunc(path, doc, func() error { called = true; return nil }); err != nil || !called {
+		t.Errorf("SaveFunc = %v, beforeWrite called: %v", err, called)
+	}
+}
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+
+	// The lock can be taken again once released
+	unlock, err = Lock(path)
+	if err != nil {
+		t.Fatalf("Lock after release: %v", err)
+	}
+
+	// Another session waits for the release
+	released := time.Now().Add(200 * time.Millisecond)
+	go func() {
+		time.Sleep(time.Until(released))
+		unlock()
+	}()
+	unlock, err = Lock(path)
+	if err != nil {
+		t.Fatalf("Lock while held: %v", err)
+	} else if time.Now().Before(released) {
+		t.Errorf("Lock didn't wait for the release of the lock")
+	}
+	unlock()
+}
@@ -0,0 +1,196 @@
+// Parsing and serialization of the variables of .env files.
+package envfile
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Portable variable names, accepted by every shell and dotenv loader.
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidName reports whether key is a portable variable name ([A-Za-z_][A-Za-z0-9_]*).
+func ValidName(key string) bool {
+	return variableName.MatchString(key)
+}
+
+// Entry is a variable read from a .env file.
+type Entry struct {
+	Key      string
+	Value    string
+	Template string // Value where ${VAR} references are expanded and "$$" is a literal "$"
+	Export   bool   // Declared with the "export " prefix
+	Comment  string // Inline comment following the value, starting with "#"
+	Line     int    // Index of the first line of the entry
+	EndLine  int    // Index of the last line of the entry (multiline values)
+}
+
+// Parse the variable starting at lines[i].
+func parseEntry(lines []string, i int) (Entry, error) {
+	entry := Entry{Line: i, EndLine: i}
+	line := strings.TrimLeft(strings.TrimSuffix(lines[i], "\r"), " \t")
+
+	// Remove the optional "export " prefix used by shell scripts
+	if rest, found := strings.CutPrefix(line, "export"); found && (strings.HasPrefix(rest, " ") || strings.HasPrefix(rest, "\t")) {
+		entry.Export = true
+		line = strings.TrimLeft(rest, " \t")
+	}
+
+	key, value, found := strings.Cut(line, "=")
+	if !found {
+		return entry, &ParseError{Line: i + 1, Message: "expected KEY=VALUE"}
+	}
+	entry.Key = strings.TrimSpace(key)
+	if entry.Key == "" {
+		return entry, &ParseError{Line: i + 1, Message: "missing variable name"}
+	}
+
+	trimmed := strings.TrimLeft(value, " \t")
+	if trimmed == "" || (trimmed[0] != '"' && trimmed[0] != '\'') {
+		entry.Value, entry.Comment = unquotedValue(value)
+		entry.Template = entry.Value
+		return entry, nil
+	}
+
+	// Quoted value, which may span several lines
+	quote := trimmed[0]
+	text := trimmed[1:]
+	var b, template strings.Builder
+	for {
+		end, closed := scanQuoted(&b, &template, text, quote)
+		if closed {
+			after := strings.TrimSpace(text[end+1:])
+			if after != "" && !strings.HasPrefix(after, "#") {
+				return entry, &ParseError{Line: entry.EndLine + 1, Message: "unexpected characters after quoted value of " + entry.Key}
+			}
+			entry.Comment = after
+			break
+		}
+
+		entry.EndLine++
+		if entry.EndLine >= len(lines) {
+			return entry, &ParseError{Line: i + 1, Message: "unterminated quoted value for " + entry.Key}
+		}
+		b.WriteByte('\n')
+		template.WriteByte('\n')
+		text = strings.TrimSuffix(lines[entry.EndLine], "\r")
+	}
+	entry.Value = b.String()
+	entry.Template = template.String()
+	return entry, nil
+}
+
+// Return the value without its surrounding whitespace, and its inline comment.
+func unquotedValue(value string) (string, string) {
+	comment := ""
+	for i := 1; i < len(value); i++ {
+		// A "#" only starts a comment when preceded by whitespace
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			value, comment = value[:i], strings.TrimSpace(value[i:])
+			break
+		}
+	}
+	return strings.TrimSpace(value), comment
+}
+
+// Write the content of a quoted value into b until the closing quote, and its
+// expansion template into template (single quotes and "\$" are literal "$").
+// Return the index of the closing quote and whether it has been found.
+func scanQuoted(b *strings.Builder, template *strings.Builder, text string, quote byte) (int, bool) {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c == quote {
+			return i, true
+		}
+
+		// Escape sequences are only interpreted inside double quotes
+		if c == '\\' && quote == '"' && i+1 < len(text) {
+			i++
+			switch text[i] {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case '$':
+				b.WriteByte('$')
+				template.WriteString("$$")
+				continue
+			case '"', '\\':
+				c = text[i]
+			default:
+				b.WriteByte('\\')
+				template.WriteByte('\\')
+				c = text[i]
+			}
+		}
+
+		b.WriteByte(c)
+		if c == '$' && quote == '\'' {
+			template.WriteString("$$")
+		} else {
+			template.WriteByte(c)
+		}
+	}
+	return len(text), false
+}
+
+// LiteralTemplate returns the expansion template of a literal value.
+func LiteralTemplate(value string) string {
+	return strings.ReplaceAll(value, "$", "$$")
+}
+
+// Check if the value can be written without quotes.
+func isPlainValue(value string) bool {
+	for _, c := range value {
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && !strings.ContainsRune("_-.,:/@+=%~", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatValue returns the value quoted and escaped so any dotenv loader reads
+// it back unchanged.
+func FormatValue(value string) string {
+	if isPlainValue(value) {
+		return value
+	}
+
+	// Single quotes keep the value literal, as long as it fits on one line
+	if !strings.ContainsAny(value, "'\n\r") {
+		return "'" + value + "'"
+	}
+
+	replacer := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "$", "\\$", "\n", "\\n", "\r", "\\r", "\t", "\\t")
+	return "\"" + replacer.Replace(value) + "\""
+}
+
+// Return the template quoted and escaped so it is read back with the same
+// references, a literal "$" being written "\$".
+func formatTemplate(template string) string {
+	if !strings.Contains(template, "$$") && isPlainValue(strings.NewReplacer("$", "", "{", "", "}", "").Replace(template)) {
+		return template
+	}
+	replacer := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "$$", "\\$", "\n", "\\n", "\r", "\\r", "\t", "\\t")
+	return "\"" + replacer.Replace(template) + "\""
+}
+
+// FormatEntry returns the line representing the entry in a .env file, with its
+// inline comment. The value is written literally, unless the entry has a
+// Template with references, which is then written as is.
+func FormatEntry(entry Entry) string {
+	value := FormatValue(entry.Value)
+	if entry.Template != "" && entry.Template != LiteralTemplate(entry.Value) {
+		value = formatTemplate(entry.Template)
+	}
+	line := entry.Key + "=" + value
+	if entry.Export {
+		line = "export " + line
+	}
+	if entry.Comment != "" {
+		line += " " + entry.Comment
+	}
+	return line
+}
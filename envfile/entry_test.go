@@ -0,0 +1,144 @@
+package envfile
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Entry
+	}{
+		{"plain", "KEY=value", Entry{Key: "KEY", Value: "value", Template: "value"}},
+		{"empty", "KEY=", Entry{Key: "KEY"}},
+		{"spaces around", "  KEY = value  ", Entry{Key: "KEY", Value: "value", Template: "value"}},
+		{"export", "export KEY=value", Entry{Key: "KEY", Value: "value", Template: "value", Export: true}},
+		{"export as name", "export=value", Entry{Key: "export", Value: "value", Template: "value"}},
+		{"inline comment", "KEY=value # note", Entry{Key: "KEY", Value: "value", Template: "value", Comment: "# note"}},
+		{"hash without space", "KEY=a#b", Entry{Key: "KEY", Value: "a#b", Template: "a#b"}},
+		{"single quotes", "KEY='a $b # c'", Entry{Key: "KEY", Value: "a $b # c", Template: "a $$b # c"}},
+		{"double quotes", `KEY="a b" # note`, Entry{Key: "KEY", Value: "a b", Template: "a b", Comment: "# note"}},
+		{"escapes", `KEY="a\nb\tc\"d\\e\$f"`, Entry{Key: "KEY", Value: "a\nb\tc\"d\\e$f", Template: "a\nb\tc\"d\\e$$f"}},
+		{"unknown escape", `KEY="a\qb"`, Entry{Key: "KEY", Value: `a\qb`, Template: `a\qb`}},
+		{"multiline", "KEY=\"line1\nline2\" # note", Entry{Key: "KEY", Value: "line1\nline2", Template: "line1\nline2", Comment: "# note", EndLine: 1}},
+		{"crlf", "KEY=value\r\n", Entry{Key: "KEY", Value: "value", Template: "value"}},
+		{"crlf quoted", "KEY='value'\r\n", Entry{Key: "KEY", Value: "value", Template: "value"}},
+		{"reference", "KEY=${OTHER}/x", Entry{Key: "KEY", Value: "${OTHER}/x", Template: "${OTHER}/x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := ParseEntries(tt.content)
+			if err != nil {
+				t.Fatalf("ParseEntries(%q): %v", tt.content, err)
+			}
+			if len(entries) != 1 || entries[0] != tt.want {
+				t.Errorf("ParseEntries(%q) = %+v, want %+v", tt.content, entries, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		line    int
+	}{
+		{"KEY", 1},
+		{"A=1\n=value", 2},
+		{"A=1\nKEY=\"open\nstill open", 2},
+		{"KEY='a' b", 1},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.content)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("Parse(%q) = %v, want a *ParseError", tt.content, err)
+		} else if parseErr.Line != tt.line {
+			t.Errorf("Parse(%q) error on line %d, want %d", tt.content, parseErr.Line, tt.line)
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	values := []string{
+		"",
+		"plain",
+		"with space",
+		"a#b",
+		"value # not a comment",
+		"it's",
+		`"quoted"`,
+		`back\slash`,
+		"$dollar and ${REF}",
+		"it's $5",
+		"multi\nline",
+		"windows\r\nline",
+		"tab\there",
+		"ünïcode",
+	}
+	for _, value := range values {
+		for _, export := range []bool{false, true} {
+			entry := Entry{Key: "KEY", Value: value, Export: export, Comment: "# note"}
+			line := FormatEntry(entry)
+			entries, err := ParseEntries(line + "\n")
+			if err != nil {
+				t.Errorf("ParseEntries(%q): %v", line, err)
+				continue
+			}
+			got := entries[0]
+			if got.Value != value || got.Export != export || got.Comment != "# note" {
+				t.Errorf("FormatEntry(%q) = %q, read back as %+v", value, line, got)
+			}
+			// Written values are never expanded
+			if got.Template != LiteralTemplate(value) {
+				t.Errorf("FormatEntry(%q) = %q, template %q, want %q", value, line, got.Template, LiteralTemplate(value))
+			}
+		}
+	}
+}
+
+func TestFormatTemplateRoundTrip(t *testing.T) {
+	templates := []string{
+		"${HOST}",
+		"http://${HOST}:${PORT:-80}/x",
+		"${USER:?need a user}",
+		"$$literal and ${REF}",
+		"with space ${REF}",
+		"multi\n${REF}",
+		`back\slash ${REF}`,
+		`"quoted" ${REF}`,
+	}
+	for _, template := range templates {
+		entry := Entry{Key: "KEY", Value: strings.ReplaceAll(template, "$$", "$"), Template: template}
+		line := FormatEntry(entry)
+		entries, err := ParseEntries(line + "\n")
+		if err != nil {
+			t.Errorf("ParseEntries(%q): %v", line, err)
+			continue
+		}
+		if got := entries[0].Template; got != template {
+			t.Errorf("FormatEntry(%q) = %q, template read back as %q", template, line, got)
+		}
+	}
+}
+
+func TestValidName(t *testing.T) {
+	tests := map[string]bool{
+		"KEY":        true,
+		"_key_2":     true,
+		"2KEY":       false,
+		"":           false,
+		"MY-KEY":     false,
+		"K=V":        false,
+		"X\nEVIL":    false,
+		"WITH SPACE": false,
+	}
+	for name, want := range tests {
+		if got := ValidName(name); got != want {
+			t.Errorf("ValidName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
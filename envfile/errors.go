@@ -0,0 +1,65 @@
+// Errors returned by the package.
+package envfile
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is matched (with errors.Is) by the errors about missing variables.
+var ErrNotFound = errors.New("variable not found")
+
+// ParseError reports a line of a .env file which cannot be parsed.
+type ParseError struct {
+	Line    int // Number of the line, starting at 1
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// KeyError reports a variable missing from the document.
+type KeyError struct {
+	Key string
+}
+
+func (e *KeyError) Error() string {
+	return e.Key + " not found"
+}
+
+func (e *KeyError) Unwrap() error {
+	return ErrNotFound
+}
+
+// NameError reports a variable name which cannot be written to a .env file: it
+// doesn't match ValidName.
+type NameError struct {
+	Key string
+}
+
+func (e *NameError) Error() string {
+	return fmt.Sprintf("invalid variable name %q", e.Key)
+}
+
+// ConflictError reports a file changed by someone else since the document was
+// read. Nothing has been written.
+type ConflictError struct {
+	Path     string
+	Original string // Content of the file when the document was read
+	Current  string // Content of the file when the document was saved
+}
+
+func (e *ConflictError) Error() string {
+	return e.Path + " has been changed by someone else since it was read, nothing has been written"
+}
+
+// LockError reports a file still locked by another session.
+type LockError struct {
+	Path  string
+	Owner string // Description of the session holding the lock
+}
+
+func (e *LockError) Error() string {
+	return e.Path + " is locked by " + e.Owner
+}
@@ -0,0 +1,56 @@
+// Safe writing of files.
+package envfile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes the data to a temporary file in the same directory, then
+// renames it over the target so the file is never left half written (crash,
+// full disk, Ctrl-C). The mode and owner of the file are kept.
+func WriteFile(filePath string, data []byte) (err error) {
+	// Write through symbolic links instead of replacing them
+	if target, linkErr := filepath.EvalSymlinks(filePath); linkErr == nil {
+		filePath = target
+	}
+
+	mode := os.FileMode(0644)
+	info, statErr := os.Stat(filePath)
+	if statErr == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// Remove the temporary file if anything fails before the rename
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if statErr == nil {
+		preserveOwner(tmp, info)
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), filePath); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(filePath))
+	return nil
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+
+package envfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// Give the file the owner and group of the original file. When the user isn't
+// allowed to change the owner, at least keep the group (shared files).
+func preserveOwner(file *os.File, info os.FileInfo) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	if err := file.Chown(int(stat.Uid), int(stat.Gid)); err != nil {
+		file.Chown(-1, int(stat.Gid))
+	}
+}
+
+// Flush the directory entry so a completed rename survives a crash.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// Try to take the exclusive lock of the file, without waiting.
+func tryLock(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Release the lock of the file.
+func unlockFile(f *os.File) {
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
+
+// Remove the lock file and release its lock. The file is removed first, so the
+// sessions waiting on it see it has been replaced once they get the lock.
+func releaseLock(f *os.File, lockName string) {
+	os.Remove(lockName)
+	unlockFile(f)
+	f.Close()
+}
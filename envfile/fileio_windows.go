@@ -0,0 +1,61 @@
+//go:build windows
+
+package envfile
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Flags of LockFileEx.
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+)
+
+// Returned by LockFileEx when the file is locked by another process.
+const errorLockViolation syscall.Errno = 33
+
+var (
+	kernel32     = syscall.NewLazyDLL("kernel32.dll")
+	lockFileEx   = kernel32.NewProc("LockFileEx")
+	unlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+// Files keep the owner of the directory on Windows.
+func preserveOwner(file *os.File, info os.FileInfo) {}
+
+// Renames are flushed by the system on Windows.
+func syncDir(dir string) {}
+
+// Region of the lock: one byte far past the end of the file, since locked
+// bytes cannot be read by other processes on Windows (the owner of the lock is
+// written at the start of the file).
+func lockRegion() *syscall.Overlapped {
+	return &syscall.Overlapped{OffsetHigh: 1}
+}
+
+// Try to take the exclusive lock of the file, without waiting.
+func tryLock(f *os.File) (bool, error) {
+	ok, _, err := lockFileEx.Call(f.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(lockRegion())))
+	if ok != 0 {
+		return true, nil
+	} else if err == errorLockViolation {
+		return false, nil
+	}
+	return false, err
+}
+
+// Release the lock of the file.
+func unlockFile(f *os.File) {
+	unlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(lockRegion())))
+}
+
+// Release the lock and remove the lock file. Open files cannot be removed on
+// Windows, so the file is kept when another session is waiting on it.
+func releaseLock(f *os.File, lockName string) {
+	unlockFile(f)
+	f.Close()
+	os.Remove(lockName)
+}
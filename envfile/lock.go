@@ -0,0 +1,76 @@
+// Advisory locking of the files edited by several sessions.
+package envfile
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How long to wait for a lock held by another session.
+const lockTimeout = 5 * time.Second
+
+// Lock takes the lock of the file, waiting for other sessions to release it.
+// The lock is held with flock(2) (LockFileEx on Windows) on FILE.lock, so the
+// system releases it when a session crashes. It returns the function releasing
+// the lock, or a *LockError if the lock is still held after a few seconds.
+func Lock(fileName string) (func(), error) {
+	lockName := fileName + ".lock"
+	hostname, _ := os.Hostname()
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(lockName, os.O_CREATE|os.O_RDWR, 0644)
+		if os.IsPermission(err) {
+			f, err = os.Open(lockName) // Left by a crashed session of another user
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		// The lock file may have been removed by the session releasing it
+		// between the open and the lock: it must still be the one in place
+		if locked && isLockFile(f, lockName) {
+			// Record the owner of the lock for the sessions waiting for it
+			f.Truncate(0)
+			fmt.Fprintf(f, "%d\n%s\n%d\n", os.Getpid(), hostname, time.Now().Unix())
+			return func() { releaseLock(f, lockName) }, nil
+		} else if locked {
+			unlockFile(f)
+		}
+		f.Close()
+
+		if time.Now().After(deadline) {
+			return nil, &LockError{Path: fileName, Owner: lockOwner(lockName)}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Check if the open file is still the lock file of the path.
+func isLockFile(f *os.File, lockName string) bool {
+	opened, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(lockName)
+	return err == nil && os.SameFile(opened, current)
+}
+
+// Return the description of the session holding the lock.
+func lockOwner(lockName string) string {
+	data, err := os.ReadFile(lockName)
+	fields := strings.Fields(string(data))
+	if err != nil || len(fields) < 3 {
+		return "another session" // Lock released or being written
+	}
+	timestamp, _ := strconv.ParseInt(fields[2], 10, 64)
+	return "process " + fields[0] + " on " + fields[1] + " since " + time.Unix(timestamp, 0).Format("15:04:05")
+}
@@ -0,0 +1,242 @@
+// Expansion of the ${VAR} references between the variables of a file.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Resolve the references of the variables of a file, remembering the
+// references which couldn't be resolved.
+type expander struct {
+	entries map[string]envfile.Entry
+	useEnv  bool // Fall back to the process environment for unknown variables
+	done    map[string]expansion
+	stack   []string // Variables being expanded, to detect cycles
+	missing []string // Undefined variables referenced by the current expansion
+}
+
+// Expanded value of a variable.
+type expansion struct {
+	value   string
+	missing []string // Undefined variables referenced, directly or not
+}
+
+func newExpander(entries []envfile.Entry, useEnv bool) *expander {
+	x := &expander{entries: map[string]envfile.Entry{}, useEnv: useEnv, done: map[string]expansion{}}
+	for _, entry := range entries {
+		x.entries[entry.Key] = entry // The last definition wins
+	}
+	return x
+}
+
+// Expand every variable. Return the expanded values, for each variable the
+// problems found (cycles, required variables, unresolved references), and the
+// variables which couldn't be expanded, whose raw value is kept.
+func expandEntries(entries []envfile.Entry, useEnv bool) (map[string]string, map[string][]string, []string) {
+	x := newExpander(entries, useEnv)
+	values := map[string]string{}
+	problems := map[string][]string{}
+	var failed []string
+	for _, entry := range entries {
+		x.missing = nil
+		value, _, err := x.value(entry.Key)
+		if err != nil {
+			value = entry.Value
+			problems[entry.Key] = []string{err.Error()}
+			if !slices.Contains(failed, entry.Key) {
+				failed = append(failed, entry.Key)
+			}
+		}
+		for _, name := range x.missing {
+			problems[entry.Key] = append(problems[entry.Key], "unresolved reference to "+name)
+		}
+		values[entry.Key] = value
+	}
+	return values, problems, failed
+}
+
+// Expand one definition of a variable, which may be overridden by a later one,
+// its references resolving to the effective values. Return the value (the raw
+// one if it couldn't be expanded), the problems found and whether it has been
+// expanded.
+func (x *expander) definition(entry envfile.Entry) (string, []string, bool) {
+	x.missing = nil
+	value, err := x.expand(entry.Template)
+	if err != nil {
+		return entry.Value, []string{err.Error()}, false
+	}
+	var problems []string
+	for _, name := range x.missing {
+		problems = append(problems, "unresolved reference to "+name)
+	}
+	return value, problems, true
+}
+
+// Return the expanded value of the variable and whether it is defined.
+func (x *expander) value(key string) (string, bool, error) {
+	if done, found := x.done[key]; found {
+		x.addMissing(done.missing...)
+		return done.value, true, nil
+	}
+
+	entry, found := x.entries[key]
+	if !found {
+		if x.useEnv {
+			if value, found := os.LookupEnv(key); found {
+				return value, true, nil
+			}
+		}
+		return "", false, nil
+	}
+
+	if index := slices.Index(x.stack, key); index >= 0 {
+		cycle := append(slices.Clone(x.stack[index:]), key)
+		return "", true, errors.New("circular reference " + strings.Join(cycle, " -> "))
+	}
+
+	// Collect the references missing from this variable only
+	outer := x.missing
+	x.missing = nil
+	x.stack = append(x.stack, key)
+	value, err := x.expand(entry.Template)
+	x.stack = x.stack[:len(x.stack)-1]
+	missing := x.missing
+	x.missing = outer
+	if err != nil {
+		return "", true, err
+	}
+
+	x.done[key] = expansion{value: value, missing: missing}
+	x.addMissing(missing...)
+	return value, true, nil
+}
+
+// Remember undefined variables referenced by the current expansion.
+func (x *expander) addMissing(names ...string) {
+	for _, name := range names {
+		if !slices.Contains(x.missing, name) {
+			x.missing = append(x.missing, name)
+		}
+	}
+}
+
+// Replace the $VAR and ${VAR} references of the template.
+func (x *expander) expand(template string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(template); i++ {
+		if template[i] != '$' || i+1 >= len(template) {
+			b.WriteByte(template[i])
+			continue
+		}
+
+		next := template[i+1]
+		switch {
+		case next == '$': // Escaped "$"
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := closingBrace(template, i+2)
+			if end < 0 {
+				return "", errors.New("missing \"}\" in " + template[i:])
+			}
+			value, err := x.reference(template[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i = end
+		case isNameStart(next):
+			end := i + 1
+			for end < len(template) && isNameChar(template[end]) {
+				end++
+			}
+			value, err := x.reference(template[i+1 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i = end - 1
+		default: // A lone "$" is kept
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// Resolve a reference: NAME, NAME:-default, NAME-default, NAME:?error or NAME?error.
+func (x *expander) reference(expression string) (string, error) {
+	name := expression
+	for i := 0; i < len(expression); i++ {
+		if !isNameChar(expression[i]) {
+			name = expression[:i]
+			break
+		}
+	}
+	if name == "" || !isNameStart(name[0]) {
+		return "", errors.New("invalid reference ${" + expression + "}")
+	}
+
+	value, defined, err := x.value(name)
+	if err != nil {
+		return "", err
+	}
+
+	operator := expression[len(name):]
+	switch {
+	case operator == "":
+		if !defined {
+			x.addMissing(name)
+		}
+		return value, nil
+	case strings.HasPrefix(operator, ":-"):
+		if !defined || value == "" {
+			return x.expand(operator[2:])
+		}
+	case strings.HasPrefix(operator, "-"):
+		if !defined {
+			return x.expand(operator[1:])
+		}
+	case strings.HasPrefix(operator, ":?"), strings.HasPrefix(operator, "?"):
+		if !defined || (operator[0] == ':' && value == "") {
+			message := strings.TrimPrefix(strings.TrimPrefix(operator, ":"), "?")
+			if message == "" {
+				message = "required variable is not set"
+			}
+			return "", fmt.Errorf("%s: %s", name, message)
+		}
+	default:
+		return "", errors.New("invalid reference ${" + expression + "}")
+	}
+	return value, nil
+}
+
+// Return the index of the "}" closing the reference starting at start.
+func closingBrace(template string, start int) int {
+	depth := 0
+	for i := start; i < len(template); i++ {
+		switch template[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
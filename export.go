@@ -0,0 +1,177 @@
+// Export of .env files to other formats.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Formats supported by -export.
+var exportFormats = []string{"json", "yaml", "toml", "sh", "fish", "powershell", "docker"}
+
+func export(command []string) string {
+	command[1] = addExtension(command[1])
+
+	layers, err := loadLayers(command)
+	if os.IsNotExist(err) || os.IsPermission(err) {
+		status = exitNotFound
+		return Red + "Error: File " + command[1] + " doesn't exist or cannot be read!" + Reset
+	} else if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+
+	format := "sh"
+	if index, maxIndex, found := isCommand(command, "--format"); found {
+		if index != maxIndex || !slices.Contains(exportFormats, command[index]) {
+			status = exitUsage
+			return Red + "Expected one format among " + strings.Join(exportFormats, ", ") + "!\n-help -export for more info!" + Reset
+		}
+		format = command[index]
+	}
+
+	entries := envfile.Effective(layerEntries(layers))
+	_, _, expand := isCommand(command, "--expand")
+	_, _, useEnv := isCommand(command, "--env")
+	if expand {
+		values, problems, failed := expandEntries(layerEntries(layers), useEnv)
+		if len(failed) > 0 {
+			// A required variable is missing, or a cycle: the values would be wrong
+			status = exitInvalid
+			var errs []string
+			for _, key := range failed {
+				errs = append(errs, "Error: "+key+": "+problems[key][0])
+			}
+			return Red + strings.Join(errs, "\n") + Reset
+		}
+		for i := range entries {
+			entries[i].Value = values[entries[i].Key]
+		}
+	}
+
+	// Only keep the variables starting with one of the prefixes
+	if index, _, found := isCommand(command, "--prefix"); found {
+		prefixes := arguments(command, index)
+		if len(prefixes) == 0 {
+			status = exitUsage
+			return Red + "Expected prefix(es) after --prefix!\n-help -export for more info!" + Reset
+		}
+		_, _, strip := isCommand(command, "--strip-prefix")
+		var filtered []envfile.Entry
+		for _, entry := range entries {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(entry.Key, prefix) {
+					if strip {
+						entry.Key = strings.TrimPrefix(entry.Key, prefix)
+					}
+					filtered = append(filtered, entry)
+					break
+				}
+			}
+		}
+		entries = filtered
+	}
+
+	// Shells only accept valid variable names
+	if format == "sh" || format == "fish" || format == "powershell" {
+		for _, entry := range entries {
+			if !envfile.ValidName(entry.Key) {
+				status = exitError
+				return Red + "Error: " + entry.Key + " isn't a valid variable name for " + format + "!" + Reset
+			}
+		}
+	}
+
+	output, err := exportEntries(entries, format)
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+	return output
+}
+
+// Return the variables written in the given format.
+func exportEntries(entries []envfile.Entry, format string) (string, error) {
+	var b strings.Builder
+	switch format {
+	case "json":
+		b.WriteString("{")
+		for i, entry := range entries {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n  " + jsonString(entry.Key) + ": " + jsonString(entry.Value))
+		}
+		if len(entries) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("}")
+	case "yaml":
+		for _, entry := range entries {
+			b.WriteString(yamlKey(entry.Key) + ": " + jsonString(entry.Value) + "\n")
+		}
+	case "toml":
+		for _, entry := range entries {
+			key := entry.Key
+			if !bareKey.MatchString(key) {
+				key = jsonString(key)
+			}
+			b.WriteString(key + " = " + jsonString(entry.Value) + "\n")
+		}
+	case "sh":
+		for _, entry := range entries {
+			b.WriteString("export " + entry.Key + "='" + strings.ReplaceAll(entry.Value, "'", `'\''`) + "'\n")
+		}
+	case "fish":
+		for _, entry := range entries {
+			value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(entry.Value)
+			b.WriteString("set -gx " + entry.Key + " '" + value + "'\n")
+		}
+	case "powershell":
+		for _, entry := range entries {
+			b.WriteString("$env:" + entry.Key + " = '" + strings.ReplaceAll(entry.Value, "'", "''") + "'\n")
+		}
+	case "docker":
+		// Docker reads values literally, up to the end of the line
+		for _, entry := range entries {
+			if strings.ContainsAny(entry.Value, "\n\r") {
+				return "", errors.New(entry.Key + " has a multiline value, which docker env-files don't support")
+			}
+			b.WriteString(entry.Key + "=" + entry.Value + "\n")
+		}
+	default:
+		return "", errors.New("unknown format " + format)
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// Keys which can be written without quotes in TOML.
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Return the value as a JSON string, which is also a valid YAML and TOML string.
+func jsonString(value string) string {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Return the key, quoted when YAML would read it as something else than a string.
+func yamlKey(key string) string {
+	switch strings.ToLower(key) {
+	case "y", "n", "yes", "no", "on", "off", "true", "false", "null", "~":
+		return jsonString(key)
+	}
+	if !envfile.ValidName(key) {
+		return jsonString(key)
+	}
+	return key
+}
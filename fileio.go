@@ -0,0 +1,87 @@
+// Safe writing of files.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Replace the content of the file, keeping its mode and owner.
+func overwriteFile(filePath string, content string) bool {
+	unlock, err := envfile.Lock(filePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error locking file:", err)
+		return false
+	}
+	defer unlock()
+
+	if err := saveSnapshot(filePath, false); err != nil {
+		fmt.Fprintln(os.Stderr, "Error keeping the previous version:", err)
+		return false
+	}
+	before, _ := os.ReadFile(filePath)
+	if err := envfile.WriteFile(filePath, []byte(content)); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing to file:", err)
+		return false
+	}
+	auditChange(filePath, string(before), content)
+	return true
+}
+
+// Write the document to the file, unless another session changed the file
+// since the document was read.
+func saveDocument(fileName string, doc *envfile.Document) bool {
+	before := doc.Original()
+	err := envfile.SaveFunc(fileName, doc, func() error {
+		if err := saveSnapshot(fileName, false); err != nil {
+			return fmt.Errorf("keeping the previous version: %w", err)
+		}
+		return nil
+	})
+
+	var conflict *envfile.ConflictError
+	if errors.As(err, &conflict) {
+		fmt.Fprintln(os.Stderr, Red+fileName+" has been changed by someone else since it was read, nothing has been written!"+Reset)
+		fmt.Fprintln(os.Stderr, Yellow+describeChanges(conflict.Original, conflict.Current)+Reset)
+		return false
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, Red+"Error: "+err.Error()+Reset)
+		return false
+	}
+	auditChange(fileName, before, doc.Original())
+	return true
+}
+
+// Describe the variables changed between two versions of a file.
+func describeChanges(before, after string) string {
+	oldDoc, errOld := envfile.Parse(before)
+	newDoc, errNew := envfile.Parse(after)
+	if errOld != nil || errNew != nil {
+		return "The file can no longer be parsed."
+	}
+
+	var changes []string
+	for _, key := range newDoc.Keys() {
+		old, found := oldDoc.Lookup(key)
+		current, _ := newDoc.Lookup(key)
+		if !found {
+			changes = append(changes, "+ "+key+" added")
+		} else if old.Value != current.Value {
+			changes = append(changes, "~ "+key+" changed")
+		}
+	}
+	for _, key := range oldDoc.Keys() {
+		if _, found := newDoc.Lookup(key); !found {
+			changes = append(changes, "- "+key+" removed")
+		}
+	}
+
+	if len(changes) == 0 {
+		return "Only comments or formatting have changed."
+	}
+	return "Changes made by the other session:\n" + strings.Join(changes, "\n")
+}
@@ -0,0 +1,199 @@
+// Generation of random secrets, written to the file without being displayed.
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Characters of the generated secrets.
+var charsets = map[string]string{
+	"base64url": "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_",
+	"hex":       "0123456789abcdef",
+	"alnum":     "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"symbols":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+// Kinds of generated values: a random string, a UUID or a key pair.
+var generatorTypes = []string{"secret", "uuid", "ed25519", "rsa"}
+
+// Return a random string of the given length from the characters of charset.
+func randomString(charset string, length int) (string, error) {
+	b := make([]byte, length)
+	limit := big.NewInt(int64(len(charset)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, limit) // Uniform, unlike a modulo
+		if err != nil {
+			return "", err
+		}
+		b[i] = charset[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// Return a random (version 4) UUID.
+func randomUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// Return a new key pair as PEM: the PKCS #8 private key and the PKIX public key.
+func generateKeyPair(kind string, bits int) (string, string, error) {
+	var private, public any
+	switch kind {
+	case "ed25519":
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", "", err
+		}
+		private, public = priv, pub
+	case "rsa":
+		priv, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return "", "", err
+		}
+		private, public = priv, &priv.PublicKey
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", "", err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", "", err
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	return strings.TrimSuffix(string(privatePEM), "\n"), strings.TrimSuffix(string(publicPEM), "\n"), nil
+}
+
+func gen(command []string) string {
+	command[1] = addExtension(command[1])
+
+	index, _, found := isCommand(command, "-var")
+	var names []string
+	if found {
+		names = arguments(command, index)
+	}
+	if len(names) == 0 {
+		status = exitUsage
+		return Red + "Incorrect use of the -gen command!\n-help -gen for more info!" + Reset
+	}
+
+	// Read the options, each taking one value
+	options := map[string]string{"--type": "secret", "--charset": "base64url", "--length": ""}
+	for option := range options {
+		if index, maxIndex, found := isCommand(command, option); found {
+			if index != maxIndex {
+				status = exitUsage
+				return Red + "Expected one value after " + option + "!\n-help -gen for more info!" + Reset
+			}
+			options[option] = command[index]
+		}
+	}
+	kind, charset := options["--type"], charsets[options["--charset"]]
+	if !slices.Contains(generatorTypes, kind) {
+		status = exitUsage
+		return Red + "Expected one type among " + strings.Join(generatorTypes, ", ") + "!" + Reset
+	} else if charset == "" {
+		status = exitUsage
+		return Red + "Expected one charset among base64url, hex, alnum, symbols!" + Reset
+	}
+
+	// The length is a number of characters, or the size of RSA keys in bits
+	length := 32
+	if kind == "rsa" {
+		length = 3072
+	}
+	if options["--length"] != "" {
+		n, err := strconv.Atoi(options["--length"])
+		if err != nil || n < 1 || n > 8192 || (kind == "rsa" && n < 2048) {
+			status = exitUsage
+			return Red + "Invalid length " + options["--length"] + " (RSA keys need at least 2048 bits)!" + Reset
+		}
+		length = n
+	}
+
+	if !isFileValid(command[1]) {
+		status = exitNotFound
+		return Red + command[1] + " not found!" + Reset
+	}
+	doc, err := envfile.Load(command[1])
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+	schema, err := schemaFor(command[1])
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+
+	// Generate every value before writing anything
+	values := map[string]string{}
+	var keys []string
+	for _, name := range names {
+		var value, public string
+		switch kind {
+		case "secret":
+			value, err = randomString(charset, length)
+		case "uuid":
+			value, err = randomUUID()
+		default:
+			value, public, err = generateKeyPair(kind, length)
+		}
+		if err != nil {
+			status = exitError
+			return Red + "Error: " + err.Error() + Reset
+		}
+		keys, values[name] = append(keys, name), value
+		if public != "" {
+			keys, values[name+"_PUBLIC"] = append(keys, name+"_PUBLIC"), public
+		}
+	}
+
+	result := ""
+	changed := 0
+	for _, key := range keys {
+		if err := schema.check(key, values[key]); err != nil {
+			status = exitInvalid
+			return Red + "Invalid value: " + err.Error() + Reset
+		}
+		_, exists := doc.Lookup(key)
+		if exists && !verify(Yellow+key+" already exists. Do you want to replace it with a new value? (y/n)"+Reset) {
+			result += Yellow + key + " kept." + Reset + "\n"
+			continue
+		}
+		if err := doc.Set(key, values[key]); err != nil {
+			status = exitUsage
+			return Red + "Error: " + err.Error() + "!" + Reset
+		}
+		result += Green + key + " generated." + Reset + "\n"
+		changed++
+	}
+
+	if changed == 0 {
+		status = exitDeclined
+		return strings.TrimSuffix(result, "\n")
+	} else if !saveDocument(command[1], doc) {
+		status = exitError
+		return Red + "Error when writing " + command[1] + "!" + Reset
+	}
+	return result + Green + "Values written to " + command[1] + " (-get " + command[1] + " NAME --reveal to see them)." + Reset
+}
@@ -0,0 +1,3 @@
+module github.com/Xanoor/EnvCLI
+
+go 1.22
@@ -0,0 +1,212 @@
+// Snapshots of the previous versions of the files, so a mistaken command can
+// be undone with -restore.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Number of versions kept for each file.
+const historySize = 20
+
+// A previous version of a file.
+type snapshot struct {
+	ID      int
+	Time    time.Time // When the version has been replaced or deleted
+	Deleted bool      // The file has been deleted after this version
+	Path    string
+}
+
+// Return the directory keeping the versions of the file: .envcli/history/FILE
+// next to the file.
+func historyDir(fileName string) string {
+	return filepath.Join(filepath.Dir(fileName), ".envcli", "history", filepath.Base(fileName))
+}
+
+// Return the versions of the file, from the oldest to the newest.
+func snapshots(fileName string) ([]snapshot, error) {
+	dir := historyDir(fileName)
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var list []snapshot
+	for _, file := range files {
+		// Versions are named ID.env, or ID-deleted.env when the file has been deleted
+		name, isVersion := strings.CutSuffix(file.Name(), ".env")
+		name, deleted := strings.CutSuffix(name, "-deleted")
+		id, err := strconv.Atoi(name)
+		if err != nil || !isVersion {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, snapshot{ID: id, Time: info.ModTime(), Deleted: deleted, Path: filepath.Join(dir, file.Name())})
+	}
+	slices.SortFunc(list, func(a, b snapshot) int { return a.ID - b.ID })
+	return list, nil
+}
+
+// Keep the current content of the file before it is replaced or deleted,
+// dropping the oldest versions. The caller holds the lock of the file.
+func saveSnapshot(fileName string, deleted bool) error {
+	content, err := os.ReadFile(fileName)
+	if os.IsNotExist(err) || (err == nil && len(content) == 0) {
+		return nil // Nothing to lose
+	} else if err != nil {
+		return err
+	}
+
+	list, err := snapshots(fileName)
+	if err != nil {
+		return err
+	}
+	id := 1
+	if len(list) > 0 {
+		last := list[len(list)-1]
+		if previous, err := os.ReadFile(last.Path); err == nil && bytes.Equal(previous, content) && !deleted && !last.Deleted {
+			return nil // This version is already kept
+		}
+		id = last.ID + 1
+	}
+
+	// Versions hold secrets: only the owner can read them
+	dir := historyDir(fileName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	name := strconv.Itoa(id)
+	if deleted {
+		name += "-deleted"
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".env"), content, 0600); err != nil {
+		return err
+	}
+	for ; len(list) >= historySize; list = list[1:] {
+		os.Remove(list[0].Path)
+	}
+	return nil
+}
+
+// Return the variables changed from one version to the next, like "~KEY, +NEW".
+func changedKeys(before, after string) string {
+	oldEntries, errOld := envfile.ParseEntries(before)
+	newEntries, errNew := envfile.ParseEntries(after)
+	if errOld != nil || errNew != nil {
+		return "unreadable version"
+	}
+
+	var keys []string
+	for _, change := range compareEntries(oldEntries, newEntries) {
+		if change.Kind != ' ' {
+			keys = append(keys, string(change.Kind)+change.Key)
+		}
+	}
+	if len(keys) == 0 {
+		return "comments or formatting"
+	}
+	return strings.Join(keys, ", ")
+}
+
+func history(command []string) string {
+	command[1] = addExtension(command[1])
+
+	list, err := snapshots(command[1])
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	} else if len(list) == 0 {
+		status = exitNotFound
+		return Red + "No previous version of " + command[1] + " has been kept!" + Reset
+	}
+
+	// Each version is compared with the one which replaced it
+	next, _ := getFileData(command[1])
+	result := ""
+	for i := len(list) - 1; i >= 0; i-- {
+		content, err := getFileData(list[i].Path)
+		if err != nil {
+			status = exitError
+			return Red + "Error: " + err.Error() + Reset
+		}
+
+		changes := changedKeys(content, next)
+		if list[i].Deleted {
+			changes = "file deleted"
+		}
+		result += fmt.Sprintf("%4d | %s | %s\n", list[i].ID, list[i].Time.Format("2006-01-02 15:04:05"), changes)
+		next = content
+	}
+	return Green + "Previous versions of " + command[1] + " (restore with -restore " + command[1] + " ID):\n" + Reset + strings.TrimSuffix(result, "\n")
+}
+
+func restore(command []string) string {
+	if len(command) < 3 {
+		status = exitUsage
+		return Red + "Incorrect use of the -restore command!\n-help -restore for more info!" + Reset
+	}
+	command[1] = addExtension(command[1])
+
+	id, err := strconv.Atoi(command[2])
+	if err != nil {
+		status = exitUsage
+		return Red + "Expected the ID of a version, got \"" + command[2] + "\"!\n-history " + command[1] + " to list them!" + Reset
+	}
+	list, err := snapshots(command[1])
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+	index := slices.IndexFunc(list, func(s snapshot) bool { return s.ID == id })
+	if index < 0 {
+		status = exitNotFound
+		return Red + "Version " + command[2] + " of " + command[1] + " doesn't exist!\n-history " + command[1] + " to list them!" + Reset
+	}
+	content, err := getFileData(list[index].Path)
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+
+	// Show what the restoration changes
+	current, err := getFileData(command[1])
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	} else if err != nil {
+		fmt.Println(Gray + command[1] + " has been deleted and will be recreated." + Reset)
+	} else if current == content {
+		return Green + command[1] + " is already identical to version " + command[2] + "." + Reset
+	} else {
+		fmt.Println(Gray + "Changes: " + changedKeys(current, content) + Reset)
+	}
+
+	if _, _, found := isCommand(command, "-v"); !found {
+		if response := verify(Yellow + "Are you sure you want to restore version " + command[2] + " of " + command[1] + "? (y/n)" + Reset); !response {
+			status = exitDeclined
+			return Green + "File not restored!" + Reset
+		}
+	}
+
+	// The current version is kept as well, so the restoration can be undone
+	if restored := overwriteFile(command[1], content); !restored {
+		status = exitError
+		return Red + "Error when restoring " + command[1] + "!" + Reset
+	}
+	return Green + command[1] + " has been restored to version " + command[2] + "!" + Reset
+}
@@ -0,0 +1,317 @@
+// Import of variables from other configuration formats.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Formats supported by -import.
+var importFormats = []string{"json", "yaml", "sh", "env", "docker", "properties"}
+
+// Policies for the variables already present in the file.
+var conflictPolicies = []string{"skip", "overwrite", "ask"}
+
+func importVariables(command []string) string {
+	command[1] = addExtension(command[1])
+
+	index, maxIndex, found := isCommand(command, "--from")
+	if !found || index != maxIndex {
+		status = exitUsage
+		return Red + "Incorrect use of the -import command!\n-help -import for more info!" + Reset
+	}
+	source := command[index]
+
+	// Find the format of the source, from its extension by default
+	format := formatFromExtension(source)
+	if index, maxIndex, found := isCommand(command, "--format"); found {
+		if index != maxIndex || !slices.Contains(importFormats, command[index]) {
+			status = exitUsage
+			return Red + "Expected one format among " + strings.Join(importFormats, ", ") + "!" + Reset
+		}
+		format = command[index]
+	} else if format == "" {
+		status = exitUsage
+		return Red + "Unknown format for " + source + ", use --format (" + strings.Join(importFormats, ", ") + ")!" + Reset
+	}
+
+	policy := "ask"
+	if index, maxIndex, found := isCommand(command, "--on-conflict"); found {
+		if index != maxIndex || !slices.Contains(conflictPolicies, command[index]) {
+			status = exitUsage
+			return Red + "Expected one policy among " + strings.Join(conflictPolicies, ", ") + "!" + Reset
+		}
+		policy = command[index]
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		status = exitNotFound
+		return Red + "Error: File " + source + " doesn't exist or cannot be read!" + Reset
+	}
+	imported, err := parseConfig(data, format)
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + source + ": " + err.Error() + Reset
+	}
+
+	doc, err := envfile.LoadOrNew(command[1])
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+
+	added, updated, skipped := 0, 0, 0
+	for _, entry := range imported {
+		// Scripts and .env files keep their references, other formats are literal
+		definition := envfile.Entry{Key: entry.Key, Value: entry.Value, Template: entry.Template}
+		if definition.Template == "" {
+			definition.Template = envfile.LiteralTemplate(entry.Value)
+		}
+		current, exists := doc.Lookup(entry.Key)
+		if exists && current.Value == definition.Value && current.Template == definition.Template {
+			continue
+		}
+
+		// A variable which already exists with another value follows the policy
+		overwrite := !exists || policy == "overwrite"
+		if exists && policy == "ask" {
+			overwrite = verify(Yellow + entry.Key + " already exists in " + command[1] + ". Overwrite it with the imported value? (y/n)" + Reset)
+		}
+		if !overwrite {
+			skipped++
+			continue
+		}
+		if err := doc.SetEntry(definition); err != nil {
+			status = exitInvalid
+			return Red + "Error: " + source + ": " + err.Error() + "!" + Reset
+		}
+		if exists {
+			updated++
+		} else {
+			added++
+		}
+	}
+
+	if added+updated > 0 && !saveDocument(command[1], doc) {
+		status = exitError
+		return Red + "Error when writing " + command[1] + "!" + Reset
+	}
+	return Green + fmt.Sprintf("Import done: %d added, %d updated, %d skipped.", added, updated, skipped) + Reset
+}
+
+// Guess the format of a file from its extension.
+func formatFromExtension(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".sh", ".bash", ".zsh":
+		return "sh"
+	case ".env":
+		return "env"
+	case ".list":
+		return "docker"
+	case ".properties":
+		return "properties"
+	}
+	return ""
+}
+
+// Read the variables of a configuration file, nested keys being flattened
+// (database.host becomes DATABASE_HOST).
+func parseConfig(data []byte, format string) ([]envfile.Entry, error) {
+	switch format {
+	case "json":
+		return parseJSONConfig(data)
+	case "yaml":
+		return parseYAMLConfig(string(data))
+	case "sh", "env":
+		// Export scripts are .env files with "export " prefixes
+		return envfile.ParseEntries(string(data))
+	case "docker":
+		return parseDockerEnv(string(data)), nil
+	case "properties":
+		return parseProperties(string(data)), nil
+	}
+	return nil, errors.New("unknown format " + format)
+}
+
+// Return the variable name of a nested key.
+func joinKey(prefix, key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if !isNameChar(c) {
+			name[i] = '_'
+		}
+	}
+	if prefix == "" {
+		return string(name)
+	}
+	return prefix + "_" + string(name)
+}
+
+// Read a JSON object, keeping the order of its keys.
+func parseJSONConfig(data []byte) ([]envfile.Entry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, errors.New("expected a JSON object")
+	}
+
+	var entries []envfile.Entry
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		if err := flattenJSON(decoder, joinKey("", key.(string)), &entries); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// Read the next JSON value, adding its variables to entries.
+func flattenJSON(decoder *json.Decoder, key string, entries *[]envfile.Entry) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		for i := 0; decoder.More(); i++ {
+			child := strconv.Itoa(i) // Arrays items are numbered
+			if value == '{' {
+				name, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				child = name.(string)
+			}
+			if err := flattenJSON(decoder, joinKey(key, child), entries); err != nil {
+				return err
+			}
+		}
+		_, err = decoder.Token() // Closing delimiter
+		return err
+	case string:
+		*entries = append(*entries, envfile.Entry{Key: key, Value: value})
+	case json.Number:
+		*entries = append(*entries, envfile.Entry{Key: key, Value: value.String()})
+	case bool:
+		*entries = append(*entries, envfile.Entry{Key: key, Value: strconv.FormatBool(value)})
+	case nil:
+		*entries = append(*entries, envfile.Entry{Key: key})
+	}
+	return nil
+}
+
+// Read a docker env-file: values are literal, and a name alone takes its value
+// from the environment.
+func parseDockerEnv(content string) []envfile.Entry {
+	var entries []envfile.Entry
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(strings.TrimLeft(line, " \t"), "=")
+		if !found {
+			if value, set := os.LookupEnv(trimmed); set {
+				entries = append(entries, envfile.Entry{Key: trimmed, Value: value})
+			}
+			continue
+		}
+		entries = append(entries, envfile.Entry{Key: strings.TrimSpace(key), Value: value})
+	}
+	return entries
+}
+
+// Read a Java .properties file.
+func parseProperties(content string) []envfile.Entry {
+	var entries []envfile.Entry
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		// A line ending with an odd number of backslashes continues on the next one
+		for continues(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+
+		// The key ends at the first unescaped "=", ":" or whitespace
+		end := 0
+		for end < len(line) && !strings.ContainsRune("=: \t\f", rune(line[end])) {
+			if line[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		key := line[:min(end, len(line))]
+		value := strings.TrimLeft(line[min(end, len(line)):], " \t\f")
+		if value != "" && (value[0] == '=' || value[0] == ':') {
+			value = strings.TrimLeft(value[1:], " \t\f")
+		}
+		entries = append(entries, envfile.Entry{Key: joinKey("", unescapeProperty(key)), Value: unescapeProperty(value)})
+	}
+	return entries
+}
+
+// Check if the line ends with an odd number of backslashes.
+func continues(line string) bool {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// Replace the escape sequences of a .properties key or value.
+func unescapeProperty(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i+1 >= len(text) {
+			b.WriteByte(text[i])
+			continue
+		}
+		i++
+		switch text[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if code, err := strconv.ParseUint(text[i+1:min(i+5, len(text))], 16, 32); err == nil && i+5 <= len(text) {
+				b.WriteRune(rune(code))
+				i += 4
+			} else {
+				b.WriteByte('u')
+			}
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,150 @@
+// Filling of a .env file from the example of the project.
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Values of the examples which still have to be replaced.
+var placeholders = []string{"", "changeme", "change-me", "change_me", "todo", "tbd", "xxx"}
+
+// Check if the value is a placeholder rather than a real value.
+func isPlaceholder(value string) bool {
+	return slices.Contains(placeholders, strings.ToLower(strings.TrimSpace(value)))
+}
+
+// Return the help of each variable of the document: the comments written just
+// before it, and its inline comment.
+func variableHelp(doc *envfile.Document) map[string]string {
+	help := map[string]string{}
+	var comments []string
+	for _, node := range doc.Nodes {
+		switch node.Kind {
+		case envfile.CommentNode:
+			comments = append(comments, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(node.Raw[0]), "#")))
+		case envfile.EntryNode:
+			if comment := node.Entry.Comment; comment != "" {
+				comments = append(comments, strings.TrimSpace(strings.TrimPrefix(comment, "#")))
+			}
+			if len(comments) > 0 {
+				help[node.Entry.Key] = strings.Join(comments, "\n")
+			}
+			comments = nil
+		default:
+			comments = nil // Comments separated by a blank line describe a section
+		}
+	}
+	return help
+}
+
+// Ask the value of a variable until it follows the schema. An empty answer
+// keeps the default value, and "gen" generates the value of a secret.
+func askValue(key, help, defaultValue string, schema *envSchema, hidden bool) (string, error) {
+	if help != "" {
+		fmt.Println(Gray + help + Reset)
+	}
+	message := "Value of " + key
+	if defaultValue != "" {
+		message += " [" + displayValue(key, defaultValue, false) + "]"
+	}
+	if isSecret(key) {
+		message += " (\"gen\" to generate a random value)"
+	}
+
+	for {
+		fmt.Println(Yellow + message + ":" + Reset)
+		value, err := readValue(hidden || isSecret(key))
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			value = defaultValue
+		} else if value == "gen" && isSecret(key) {
+			if value, err = randomString(charsets["base64url"], 32); err != nil {
+				return "", err
+			}
+		}
+		if err := schema.check(key, value); err != nil {
+			fmt.Println(Red + "Invalid value: " + err.Error() + Reset)
+			continue
+		}
+		return value, nil
+	}
+}
+
+// Ask the variables of the example which are missing from the file, or still
+// hold a placeholder, then write them.
+func fillFromExample(fileName, exampleName string, command []string) string {
+	example, err := envfile.Load(exampleName)
+	if err != nil {
+		status = exitNotFound
+		return Red + "Error: File " + exampleName + " doesn't exist or cannot be read!" + Reset
+	}
+	doc, err := envfile.LoadOrNew(fileName)
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+	schema, err := schemaFor(fileName)
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+	hidden, _ := promptOptions(command)
+	help := variableHelp(example)
+
+	filled := 0
+	for _, entry := range envfile.Effective(example.Entries()) {
+		if current, found := doc.Lookup(entry.Key); found && !isPlaceholder(current.Value) {
+			continue
+		}
+
+		// The example value is proposed, unless it is a placeholder
+		defaultValue := ""
+		if rule := schema.rule(entry.Key); !isPlaceholder(entry.Value) {
+			defaultValue = entry.Value
+		} else if rule != nil && rule.HasDefault {
+			defaultValue = rule.Default
+		}
+		value, err := askValue(entry.Key, help[entry.Key], defaultValue, schema, hidden)
+		if err != nil {
+			break // End of the input: the values typed so far are kept
+		}
+
+		if err := doc.Set(entry.Key, value); err != nil {
+			status = exitInvalid
+			return Red + "Error: " + exampleName + ": " + err.Error() + "!" + Reset
+		}
+		filled++
+	}
+
+	if filled == 0 {
+		return Green + "Every variable of " + exampleName + " is already set in " + fileName + "." + Reset
+	} else if !saveDocument(fileName, doc) {
+		status = exitError
+		return Red + "Error when writing " + fileName + "!" + Reset
+	}
+	return Green + fmt.Sprintf("%d variable(s) of %s written to %s.", filled, exampleName, fileName) + Reset
+}
+
+// Return the example given with --from, or .env.example next to the file.
+func exampleFile(command []string) string {
+	if index, maxIndex, found := isCommand(command, "--from"); found && index == maxIndex {
+		return command[index]
+	}
+	return filepath.Join(filepath.Dir(command[1]), ".env.example")
+}
+
+func initFile(command []string) string {
+	command[1] = addExtension(command[1])
+	if index, maxIndex, found := isCommand(command, "--from"); found && index != maxIndex {
+		status = exitUsage
+		return Red + "Expected one file name after --from!\n-help -init for more info!" + Reset
+	}
+	return fillFromExample(command[1], exampleFile(command), command)
+}
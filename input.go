@@ -0,0 +1,78 @@
+// Prompting of variable values, without echo for secrets.
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// Ask the value of the variable until it follows the schema. The values of
+// secrets, or every value with hidden, aren't echoed, and are typed twice with
+// confirm.
+func promptValue(message string, key string, schema *envSchema, hidden bool, confirm bool) (string, error) {
+	hidden = hidden || isSecret(key)
+	for {
+		fmt.Println(Yellow + message + Reset)
+		value, err := readValue(hidden)
+		if err != nil {
+			return "", err
+		}
+		if err := schema.check(key, value); err != nil {
+			fmt.Println(Red + "Invalid value: " + err.Error() + Reset)
+			continue
+		}
+
+		// Scripts writing to stdin don't type the value twice
+		if hidden && confirm && isTerminal(os.Stdin) {
+			fmt.Println(Yellow + "Type the value of " + key + " again:" + Reset)
+			again, err := readValue(true)
+			if err != nil {
+				return "", err
+			} else if again != value {
+				fmt.Println(Red + "The values don't match!" + Reset)
+				continue
+			}
+		}
+		return value, nil
+	}
+}
+
+// Return the -hidden and -confirm options of the command.
+func promptOptions(command []string) (bool, bool) {
+	_, _, hidden := isCommand(command, "-hidden")
+	_, _, confirm := isCommand(command, "-confirm")
+	return hidden, confirm
+}
+
+// Read a line, without echoing it if hidden is set and stdin is a terminal.
+func readValue(hidden bool) (string, error) {
+	if !hidden || !isTerminal(os.Stdin) {
+		return readLine()
+	}
+	restore, err := disableEcho()
+	if err != nil {
+		return readLine()
+	}
+
+	// The echo must come back even if the input is interrupted
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+			restore()
+			fmt.Println()
+			os.Exit(exitDeclined)
+		case <-done:
+		}
+	}()
+
+	line, err := readLine()
+	close(done)
+	signal.Stop(signals)
+	restore()
+	fmt.Println() // The Enter key hasn't been echoed
+	return line, err
+}
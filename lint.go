@@ -0,0 +1,178 @@
+// Detection of the common mistakes of .env files.
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// A problem found in a .env file.
+type lintIssue struct {
+	Line    int // Line of the problem, 0 for the whole file
+	Message string
+	Fixable bool // -lint --fix can repair it without changing any value
+}
+
+// Return the problems of the document.
+func lintDocument(doc *envfile.Document) []lintIssue {
+	var issues []lintIssue
+	defined := map[string]int{} // Line of the last definition of each variable
+
+	for _, node := range doc.Nodes {
+		if node.Kind != envfile.EntryNode {
+			continue
+		}
+		entry := node.Entry
+		line := entry.Line + 1
+
+		if previous, found := defined[entry.Key]; found {
+			issues = append(issues, lintIssue{line, fmt.Sprintf("%s is already defined on line %d (the last definition wins)", entry.Key, previous), true})
+		}
+		defined[entry.Key] = line
+
+		if !envfile.ValidName(entry.Key) {
+			issues = append(issues, lintIssue{line, entry.Key + " isn't a valid variable name ([A-Za-z_][A-Za-z0-9_]*)", false})
+		}
+
+		_, key, value := splitEntryLine(node.Raw[0])
+		if strings.TrimSpace(key) != key || (strings.TrimSpace(value) != "" && strings.TrimLeft(value, " \t") != value) {
+			issues = append(issues, lintIssue{line, "whitespace around \"=\" of " + entry.Key, true})
+		}
+		if isUnquoted(value) && strings.ContainsAny(entry.Value, " \t#") {
+			// Values with "$" would stop being expanded once quoted
+			issues = append(issues, lintIssue{line, "the value of " + entry.Key + " contains spaces or \"#\" and should be quoted", !strings.Contains(entry.Value, "$")})
+		}
+	}
+
+	if doc.CRLF {
+		issues = append(issues, lintIssue{0, "Windows (CRLF) line endings", true})
+	}
+	if len(doc.Nodes) > 0 && !doc.TrailingNewline {
+		issues = append(issues, lintIssue{0, "missing newline at the end of the file", true})
+	}
+	return issues
+}
+
+// Repair the fixable problems of the document: earlier definitions of
+// duplicated variables are removed, and entries are rewritten without
+// whitespace around "=" and with their value quoted when needed.
+func fixDocument(doc *envfile.Document) {
+	// Only keep the last definition of each variable
+	last := map[string]int{}
+	for i, node := range doc.Nodes {
+		if node.Kind == envfile.EntryNode {
+			last[node.Entry.Key] = i
+		}
+	}
+	nodes := doc.Nodes[:0]
+	for i, node := range doc.Nodes {
+		if node.Kind == envfile.EntryNode && last[node.Entry.Key] != i {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	doc.Nodes = nodes
+
+	for i, node := range doc.Nodes {
+		for j := range node.Raw {
+			node.Raw[j] = strings.TrimSuffix(node.Raw[j], "\r")
+		}
+		if node.Kind == envfile.EntryNode {
+			node.Raw[0] = fixEntryLine(node.Raw[0], node.Entry)
+		}
+		doc.Nodes[i] = node
+	}
+	doc.CRLF = false
+	doc.TrailingNewline = len(doc.Nodes) > 0
+}
+
+// Return the first line of the entry without whitespace around "=", and with
+// its value quoted if it contains spaces or "#".
+func fixEntryLine(line string, entry envfile.Entry) string {
+	prefix, key, value := splitEntryLine(line)
+	value = strings.TrimLeft(value, " \t")
+	if isUnquoted(value) && strings.ContainsAny(entry.Value, " \t#") && !strings.Contains(entry.Value, "$") {
+		value = envfile.FormatValue(entry.Value)
+		if entry.Comment != "" {
+			value += " " + entry.Comment
+		}
+	}
+	return prefix + strings.TrimSpace(key) + "=" + value
+}
+
+// Split the first line of an entry into its indentation and "export " prefix,
+// its key and its value (with the surrounding whitespace).
+func splitEntryLine(line string) (string, string, string) {
+	rest := strings.TrimLeft(line, " \t")
+	prefix := line[:len(line)-len(rest)]
+	if after, found := strings.CutPrefix(rest, "export"); found && (strings.HasPrefix(after, " ") || strings.HasPrefix(after, "\t")) {
+		trimmed := strings.TrimLeft(after, " \t")
+		prefix += rest[:len(rest)-len(trimmed)]
+		rest = trimmed
+	}
+	key, value, _ := strings.Cut(rest, "=")
+	return prefix, key, value
+}
+
+// Check if the raw value isn't quoted.
+func isUnquoted(value string) bool {
+	value = strings.TrimLeft(value, " \t")
+	return value != "" && value[0] != '"' && value[0] != '\''
+}
+
+func lint(command []string) string {
+	command[1] = addExtension(command[1])
+
+	doc, err := envfile.Load(command[1])
+	if err != nil {
+		status = exitInvalid
+		return Red + "Error: " + command[1] + ": " + err.Error() + Reset
+	}
+
+	issues := lintDocument(doc)
+	_, _, fix := isCommand(command, "--fix")
+	fixed := 0
+	if fix {
+		for _, issue := range issues {
+			if issue.Fixable {
+				fixed++
+			}
+		}
+		if fixed > 0 {
+			fixDocument(doc)
+			if !saveDocument(command[1], doc) {
+				status = exitError
+				return Red + "Error when writing " + command[1] + "!" + Reset
+			}
+		}
+	}
+
+	result := ""
+	remaining := 0
+	for _, issue := range issues {
+		location := command[1]
+		if issue.Line > 0 {
+			location += fmt.Sprintf(":%d", issue.Line)
+		}
+		switch {
+		case fix && issue.Fixable:
+			result += Green + location + ": fixed: " + issue.Message + Reset + "\n"
+		case issue.Fixable:
+			result += Yellow + location + ": " + issue.Message + " (fixable with --fix)" + Reset + "\n"
+			remaining++
+		default:
+			result += Red + location + ": " + issue.Message + Reset + "\n"
+			remaining++
+		}
+	}
+
+	if remaining > 0 {
+		status = exitInvalid
+		return result + Red + fmt.Sprintf("%d problem(s) found in %s.", remaining, command[1]) + Reset
+	} else if fixed > 0 {
+		return result + Green + fmt.Sprintf("%d problem(s) fixed in %s.", fixed, command[1]) + Reset
+	}
+	return Green + "No problem found in " + command[1] + "." + Reset
+}
@@ -0,0 +1,80 @@
+// Hiding of secret values.
+package main
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Patterns of the names of the variables holding secrets, whose values are
+// masked unless --reveal is given. ENVCLI_SECRET_PATTERNS replaces them with
+// a comma-separated list.
+var defaultSecretPatterns = []string{"*_KEY", "*_SECRET", "*PASSWORD*", "*TOKEN*"}
+
+// Return the patterns of the secret variable names.
+func secretPatterns() []string {
+	if value, found := os.LookupEnv("ENVCLI_SECRET_PATTERNS"); found {
+		var patterns []string
+		for _, pattern := range strings.Split(value, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				patterns = append(patterns, pattern)
+			}
+		}
+		return patterns
+	}
+	return defaultSecretPatterns
+}
+
+// Check if the variable holds a secret. Names are compared without case.
+func isSecret(key string) bool {
+	for _, pattern := range secretPatterns() {
+		if matched, _ := path.Match(strings.ToUpper(pattern), strings.ToUpper(key)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the value to display: masked for secrets, unless reveal is set.
+func displayValue(key, value string, reveal bool) string {
+	if reveal || !isSecret(key) {
+		return value
+	}
+	return maskValue(value)
+}
+
+// Return the line of the entry to display, its value being masked for secrets
+// unless reveal is set.
+func displayEntry(entry envfile.Entry, reveal bool) string {
+	if reveal || !isSecret(entry.Key) || entry.Value == "" {
+		return envfile.FormatEntry(entry)
+	}
+	line := entry.Key + "=" + maskValue(entry.Value)
+	if entry.Export {
+		line = "export " + line
+	}
+	if entry.Comment != "" {
+		line += " " + entry.Comment
+	}
+	return line
+}
+
+// Return the value with its characters hidden, the first and last ones excepted
+// for long values, followed by its length.
+func maskValue(value string) string {
+	length := utf8.RuneCountInString(value)
+	if length == 0 {
+		return ""
+	}
+	runes := []rune(value)
+	masked := "****"
+	if length >= 12 {
+		masked = string(runes[0]) + "****" + string(runes[length-1])
+	}
+	return masked + " (" + strconv.Itoa(length) + " chars)"
+}
@@ -0,0 +1,84 @@
+// Selection of the variables targeted by a command.
+package main
+
+import (
+	"errors"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Return the function selecting the variables requested by the command: the
+// given names exactly, or the patterns following --glob, --regex or --value.
+// Also return a description of the selection for messages.
+func entryMatcher(command []string, names []string) (func(entry envfile.Entry) bool, string, error) {
+	if index, _, found := isCommand(command, "--glob"); found {
+		patterns := arguments(command, index)
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, "", errors.New("invalid glob pattern " + pattern)
+			}
+		}
+		if len(patterns) == 0 {
+			return nil, "", errors.New("expected pattern(s) after --glob")
+		}
+		return func(entry envfile.Entry) bool {
+			return slices.ContainsFunc(patterns, func(pattern string) bool {
+				matched, _ := path.Match(pattern, entry.Key)
+				return matched
+			})
+		}, strings.Join(patterns, ", "), nil
+	}
+
+	if index, _, found := isCommand(command, "--regex"); found {
+		sources := arguments(command, index)
+		var expressions []*regexp.Regexp
+		for _, expression := range sources {
+			compiled, err := regexp.Compile(expression)
+			if err != nil {
+				return nil, "", errors.New("invalid regular expression " + expression)
+			}
+			expressions = append(expressions, compiled)
+		}
+		if len(expressions) == 0 {
+			return nil, "", errors.New("expected expression(s) after --regex")
+		}
+		return func(entry envfile.Entry) bool {
+			return slices.ContainsFunc(expressions, func(expression *regexp.Regexp) bool {
+				return expression.MatchString(entry.Key)
+			})
+		}, strings.Join(sources, ", "), nil
+	}
+
+	if index, _, found := isCommand(command, "--value"); found {
+		searches := arguments(command, index)
+		if len(searches) == 0 {
+			return nil, "", errors.New("expected value(s) after --value")
+		}
+		return func(entry envfile.Entry) bool {
+			return slices.ContainsFunc(searches, func(search string) bool {
+				return strings.Contains(entry.Value, search)
+			})
+		}, "value " + strings.Join(searches, ", "), nil
+	}
+
+	if len(names) == 0 {
+		return nil, "", errors.New("expected variable name(s)")
+	}
+	return func(entry envfile.Entry) bool {
+		return slices.Contains(names, entry.Key)
+	}, strings.Join(names, ", "), nil
+}
+
+// Check if the command selects variables with --glob, --regex or --value.
+func hasPatternOption(command []string) bool {
+	for _, option := range []string{"--glob", "--regex", "--value"} {
+		if _, _, found := isCommand(command, option); found {
+			return true
+		}
+	}
+	return false
+}
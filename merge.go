@@ -0,0 +1,99 @@
+// Merging of .env files.
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Strategies for the variables defined with different values.
+var mergeStrategies = []string{"ours", "theirs", "ask", "fail"}
+
+func merge(command []string) string {
+	command[1] = addExtension(command[1])
+	sources := arguments(command, 2)
+	if len(sources) == 0 {
+		status = exitUsage
+		return Red + "Incorrect use of the -merge command!\n-help -merge for more info!" + Reset
+	}
+
+	strategy := "ask"
+	if index, maxIndex, found := isCommand(command, "--strategy"); found {
+		if index != maxIndex || !slices.Contains(mergeStrategies, command[index]) {
+			status = exitUsage
+			return Red + "Expected one strategy among " + strings.Join(mergeStrategies, ", ") + "!" + Reset
+		}
+		strategy = command[index]
+	}
+
+	// The target is created if it doesn't exist yet
+	doc, err := envfile.LoadOrNew(command[1])
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+
+	var summary, conflicts []string
+	changed := 0
+	for _, source := range sources {
+		source = addExtension(source)
+		sourceDoc, err := envfile.Load(source)
+		if err != nil {
+			status = exitNotFound
+			return Red + "Error: File " + source + " doesn't exist or cannot be read!" + Reset
+		}
+
+		// Definitions are copied as written, so their references are kept
+		for _, entry := range envfile.Effective(sourceDoc.Entries()) {
+			definition := envfile.Entry{Key: entry.Key, Value: entry.Value, Template: entry.Template}
+			current, exists := doc.Lookup(entry.Key)
+			if !exists {
+				if err := doc.AppendEntry(definition); err != nil {
+					status = exitInvalid
+					return Red + "Error: " + source + ": " + err.Error() + "!" + Reset
+				}
+				summary = append(summary, Green+"+ "+entry.Key+" added from "+source+Reset)
+				changed++
+				continue
+			} else if current.Value == entry.Value && current.Template == entry.Template {
+				continue
+			}
+
+			// Conflict: the variable has another value in the source
+			takeTheirs := strategy == "theirs"
+			switch strategy {
+			case "fail":
+				conflicts = append(conflicts, entry.Key+" ("+source+")")
+				continue
+			case "ask":
+				takeTheirs = verify(Yellow + entry.Key + " differs in " + source + ". Take the value of " + source + "? (y/n)" + Reset)
+			}
+			if takeTheirs {
+				if err := doc.SetEntry(definition); err != nil {
+					status = exitInvalid
+					return Red + "Error: " + source + ": " + err.Error() + "!" + Reset
+				}
+				summary = append(summary, Yellow+"~ "+entry.Key+" replaced by the value of "+source+Reset)
+				changed++
+			} else {
+				summary = append(summary, Gray+"= "+entry.Key+" kept, "+source+" ignored"+Reset)
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		status = exitError
+		return Red + "Merge aborted, conflicting variable(s): " + strings.Join(conflicts, ", ") + Reset
+	}
+	if changed == 0 {
+		return strings.Join(append(summary, Green+"Nothing to merge, "+command[1]+" is up to date."+Reset), "\n")
+	}
+	if !saveDocument(command[1], doc) {
+		status = exitError
+		return Red + "Error when writing " + command[1] + "!" + Reset
+	}
+	return strings.Join(summary, "\n") + "\n" + Green + fmt.Sprintf("%d variable(s) merged into %s.", changed, command[1]) + Reset
+}
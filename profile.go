@@ -0,0 +1,117 @@
+// Layered environments: a file completed by its local and profile variants.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// A file of a layered environment.
+type envLayer struct {
+	Path string
+	Doc  *envfile.Document
+}
+
+// Return the files of the profile, from the lowest to the highest precedence:
+// FILE, FILE.local, FILE.<profile> and FILE.<profile>.local (.env, .env.local,
+// .env.staging and .env.staging.local for the file .env).
+func profileLayers(fileName, profile string) []string {
+	layers := []string{fileName, fileName + ".local"}
+	if profile != "" {
+		layers = append(layers, fileName+"."+profile, fileName+"."+profile+".local")
+	}
+	return layers
+}
+
+// Load the file of the command, or the existing layers of its profile when
+// the -profile option is given (-profile alone only adds FILE.local).
+func loadLayers(command []string) ([]envLayer, error) {
+	index, maxIndex, found := isCommand(command, "-profile")
+	if !found {
+		doc, err := envfile.Load(command[1])
+		if err != nil {
+			return nil, err
+		}
+		return []envLayer{{Path: command[1], Doc: doc}}, nil
+	}
+
+	profile := ""
+	if index <= maxIndex {
+		profile = command[index]
+	}
+	if strings.ContainsAny(profile, `/\`) {
+		return nil, errors.New("invalid profile name " + profile)
+	}
+
+	var layers []envLayer
+	for _, path := range profileLayers(command[1], profile) {
+		doc, err := envfile.Load(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		layers = append(layers, envLayer{Path: path, Doc: doc})
+	}
+	if len(layers) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return layers, nil
+}
+
+// Return the variables of every layer, in precedence order (the last
+// definition wins).
+func layerEntries(layers []envLayer) []envfile.Entry {
+	var entries []envfile.Entry
+	for _, layer := range layers {
+		entries = append(entries, layer.Doc.Entries()...)
+	}
+	return entries
+}
+
+// Return the name of the layers, for messages.
+func layerNames(layers []envLayer) string {
+	var names []string
+	for _, layer := range layers {
+		names = append(names, layer.Path)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Show the layers defining each selected variable, and which one wins.
+func explain(layers []envLayer, match func(entry envfile.Entry) bool, description string, reveal bool) string {
+	result := ""
+	for _, entry := range envfile.Effective(layerEntries(layers)) {
+		if !match(entry) {
+			continue
+		}
+
+		// Every definition, the last one being the effective value
+		var definitions []string
+		for _, layer := range layers {
+			for _, defined := range layer.Doc.Entries() {
+				if defined.Key == entry.Key {
+					definitions = append(definitions, fmt.Sprintf("%s:%d %s", layer.Path, defined.Line+1, displayEntry(defined, reveal)))
+				}
+			}
+		}
+		result += Green + displayEntry(entry, reveal) + Reset + "\n"
+		for i, definition := range definitions {
+			if i == len(definitions)-1 {
+				result += "  " + definition + Green + " (effective)" + Reset + "\n"
+			} else {
+				result += Gray + "  " + definition + " (overridden)" + Reset + "\n"
+			}
+		}
+	}
+
+	if result == "" {
+		status = exitNotFound
+		return Red + description + " variable/value doesn't exist!" + Reset
+	}
+	return Green + "Layers: " + layerNames(layers) + "\n" + Reset + strings.TrimSuffix(result, "\n")
+}
@@ -0,0 +1,123 @@
+// Execution of programs with the variables of a .env file.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+func run(command []string) string {
+	command[1] = addExtension(command[1])
+
+	// The program and its arguments follow "--"
+	separator := slices.Index(command, endOfOptions)
+	if separator < 0 || separator == len(command)-1 {
+		status = exitUsage
+		return Red + "Incorrect use of the -run command!\n-help -run for more info!" + Reset
+	}
+	options, program := command[:separator], command[separator+1:]
+
+	_, _, clean := isCommand(options, "--clean")
+	_, _, expand := isCommand(options, "--expand")
+	var allowed []string
+	if index, _, found := isCommand(options, "--allow"); found {
+		allowed = arguments(options, index)
+		for _, pattern := range allowed {
+			if _, err := path.Match(pattern, ""); err != nil {
+				status = exitUsage
+				return Red + "Invalid pattern " + pattern + " after --allow!" + Reset
+			}
+		}
+	}
+
+	layers, err := loadLayers(options)
+	if os.IsNotExist(err) || os.IsPermission(err) {
+		status = exitNotFound
+		return Red + "Error: File " + command[1] + " doesn't exist or cannot be read!" + Reset
+	} else if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+
+	// Values are passed as written, unless --expand resolves their references
+	// like a shell would, from the environment of the program when it is
+	// inherited. The values which cannot be expanded are passed as written.
+	entries := envfile.Effective(layerEntries(layers))
+	values := map[string]string{}
+	for _, entry := range entries {
+		values[entry.Key] = entry.Value
+	}
+	if expand {
+		var problems map[string][]string
+		values, problems, _ = expandEntries(entries, !clean)
+		for _, entry := range entries {
+			for _, problem := range problems[entry.Key] {
+				fmt.Fprintln(os.Stderr, Yellow+"Warning: "+entry.Key+": "+problem+Reset)
+			}
+		}
+	}
+
+	cmd := exec.Command(program[0], program[1:]...)
+	cmd.Env = childEnvironment(entries, values, clean, allowed)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		status = exitNotFound
+		return Red + "Error: " + err.Error() + Reset
+	}
+
+	// Signals received while the program runs are its own
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	err = cmd.Wait()
+	signal.Stop(signals)
+	close(done)
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+	status = exitStatus(cmd.ProcessState)
+	return ""
+}
+
+// Return the environment of the program: the variables of the file, added to
+// the environment of EnvCLI unless clean is set. The allowed variables of the
+// environment (names or glob patterns) are always passed.
+func childEnvironment(entries []envfile.Entry, values map[string]string, clean bool, allowed []string) []string {
+	var env []string
+	for _, variable := range os.Environ() {
+		name, _, _ := strings.Cut(variable, "=")
+		if !clean || slices.ContainsFunc(allowed, func(pattern string) bool {
+			matched, _ := path.Match(pattern, name)
+			return matched
+		}) {
+			env = append(env, variable)
+		}
+	}
+
+	// Later values win over the environment
+	for _, entry := range entries {
+		env = append(env, entry.Key+"="+values[entry.Key])
+	}
+	return env
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// Signals passed on to the programs started by -run. Windows delivers Ctrl+C
+// to every process of the console, so only os.Interrupt is caught.
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+// Return the exit status of the program.
+func exitStatus(state *os.ProcessState) int {
+	return state.ExitCode()
+}
@@ -0,0 +1,247 @@
+// Validation of .env files against a schema.
+//
+// A schema declares one variable per line, followed by its type and options:
+//
+//	# test.env.schema
+//	DATABASE_URL url required
+//	PORT port default=8080
+//	LOG_LEVEL string enum=debug,info,warn,error default=info
+//	API_KEY string required pattern='^[a-f0-9]{32}$'
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Types of values a schema can declare.
+var schemaTypes = []string{"string", "int", "bool", "url", "duration", "port", "email"}
+
+// Rules of a variable declared in a schema.
+type schemaRule struct {
+	Key        string
+	Type       string
+	Required   bool
+	Default    string
+	HasDefault bool
+	Enum       []string
+	Pattern    *regexp.Regexp
+}
+
+// Rules of the variables of a .env file.
+type envSchema struct {
+	Path  string
+	Rules []schemaRule
+}
+
+// Parse the content of a schema file.
+func parseSchema(content string) (*envSchema, error) {
+	schema := &envSchema{}
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields, err := tokenize(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		rule := schemaRule{Key: fields[0], Type: "string"}
+		for _, field := range fields[1:] {
+			option, value, hasValue := strings.Cut(field, "=")
+			switch {
+			case !hasValue && slices.Contains(schemaTypes, option):
+				rule.Type = option
+			case !hasValue && option == "required":
+				rule.Required = true
+			case hasValue && option == "default":
+				rule.Default, rule.HasDefault = value, true
+			case hasValue && option == "enum":
+				rule.Enum = strings.Split(value, ",")
+			case hasValue && option == "pattern":
+				if rule.Pattern, err = regexp.Compile(value); err != nil {
+					return nil, fmt.Errorf("line %d: invalid pattern %s", i+1, value)
+				}
+			default:
+				return nil, fmt.Errorf("line %d: unknown option %s (types: %s)", i+1, field, strings.Join(schemaTypes, ", "))
+			}
+		}
+
+		if rule.HasDefault {
+			if err := rule.check(rule.Default); err != nil {
+				return nil, fmt.Errorf("line %d: invalid default value: %v", i+1, err)
+			}
+		}
+		schema.Rules = append(schema.Rules, rule)
+	}
+	return schema, nil
+}
+
+// Return the schema of the .env file: FILE.env.schema, or .env.schema in the
+// same directory. Return nil if there is none.
+func schemaFor(fileName string) (*envSchema, error) {
+	for _, path := range []string{fileName + ".schema", filepath.Join(filepath.Dir(fileName), ".env.schema")} {
+		schema, err := loadSchema(path)
+		if err == nil || !os.IsNotExist(err) {
+			return schema, err
+		}
+	}
+	return nil, nil
+}
+
+// Read and parse a schema file.
+func loadSchema(path string) (*envSchema, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := parseSchema(string(content))
+	if err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+	schema.Path = path
+	return schema, nil
+}
+
+// Return the rule of the variable, or nil if the schema doesn't declare it.
+func (schema *envSchema) rule(key string) *schemaRule {
+	if schema == nil {
+		return nil
+	}
+	for i := range schema.Rules {
+		if schema.Rules[i].Key == key {
+			return &schema.Rules[i]
+		}
+	}
+	return nil
+}
+
+// Check the value of a variable. Variables without rule accept any value.
+func (schema *envSchema) check(key, value string) error {
+	if rule := schema.rule(key); rule != nil {
+		return rule.check(value)
+	}
+	return nil
+}
+
+// Check that the value follows the rule.
+func (rule schemaRule) check(value string) error {
+	if value == "" {
+		if rule.Required {
+			return errors.New(rule.Key + " is required")
+		}
+		return nil
+	}
+
+	var err error
+	switch rule.Type {
+	case "int":
+		_, err = strconv.Atoi(value)
+	case "bool":
+		if !slices.Contains([]string{"true", "false", "1", "0", "yes", "no", "on", "off"}, strings.ToLower(value)) {
+			err = errors.New("not a boolean")
+		}
+	case "url":
+		if u, parseErr := url.Parse(value); parseErr != nil || u.Scheme == "" || u.Host == "" {
+			err = errors.New("not an absolute URL")
+		}
+	case "duration":
+		_, err = time.ParseDuration(value)
+	case "port":
+		if port, parseErr := strconv.Atoi(value); parseErr != nil || port < 1 || port > 65535 {
+			err = errors.New("not a port between 1 and 65535")
+		}
+	case "email":
+		if address, parseErr := mail.ParseAddress(value); parseErr != nil || address.Address != value {
+			err = errors.New("not an email address")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s must be of type %s (%q)", rule.Key, rule.Type, value)
+	}
+
+	if len(rule.Enum) > 0 && !slices.Contains(rule.Enum, value) {
+		return fmt.Errorf("%s must be one of %s", rule.Key, strings.Join(rule.Enum, ", "))
+	}
+	if rule.Pattern != nil && !rule.Pattern.MatchString(value) {
+		return fmt.Errorf("%s must match %s", rule.Key, rule.Pattern)
+	}
+	return nil
+}
+
+// Check the variables of a file. Return the errors and the warnings found.
+func (schema *envSchema) validate(entries []envfile.Entry) ([]string, []string) {
+	var errs, warnings []string
+	values := map[string]string{}
+	for _, entry := range envfile.Effective(entries) {
+		values[entry.Key] = entry.Value
+		if schema.rule(entry.Key) == nil {
+			warnings = append(warnings, entry.Key+" isn't declared in the schema")
+		}
+	}
+
+	for _, rule := range schema.Rules {
+		value, found := values[rule.Key]
+		if !found {
+			if rule.Required && !rule.HasDefault {
+				errs = append(errs, rule.Key+" is required but missing")
+			}
+			continue
+		}
+		if err := rule.check(value); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	return errs, warnings
+}
+
+func validate(command []string) string {
+	command[1] = addExtension(command[1])
+
+	doc, err := envfile.Load(command[1])
+	if err != nil {
+		status = exitNotFound
+		return Red + "Error: File " + command[1] + " doesn't exist or cannot be read!" + Reset
+	}
+
+	// Use the schema given with --schema, or the schema next to the file
+	var schema *envSchema
+	if index, maxIndex, found := isCommand(command, "--schema"); found && index == maxIndex {
+		schema, err = loadSchema(command[index])
+	} else {
+		schema, err = schemaFor(command[1])
+	}
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	} else if schema == nil {
+		status = exitNotFound
+		return Red + "No schema found for " + command[1] + " (" + command[1] + ".schema or .env.schema)!" + Reset
+	}
+
+	errs, warnings := schema.validate(doc.Entries())
+	result := ""
+	for _, warning := range warnings {
+		result += Yellow + "Warning: " + warning + Reset + "\n"
+	}
+	for _, err := range errs {
+		result += Red + "Error: " + err + Reset + "\n"
+	}
+	if len(errs) > 0 {
+		status = exitInvalid
+		return result + Red + command[1] + " doesn't follow " + schema.Path + "!" + Reset
+	}
+	return result + Green + command[1] + " follows " + schema.Path + "." + Reset
+}
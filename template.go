@@ -0,0 +1,134 @@
+// Example files listing the variables of a .env file without their values.
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// Return the line of the variable in the example: its default value from the
+// schema, or no value with a hint of what is expected, after its inline comment.
+func templateLine(entry envfile.Entry, rule *schemaRule) string {
+	line := entry.Key + "="
+	if entry.Export {
+		line = "export " + line
+	}
+	comment := entry.Comment
+	if rule != nil && rule.HasDefault {
+		line += envfile.FormatValue(rule.Default)
+	} else if hints := schemaHints(rule); hints != "" && comment != "" {
+		comment += " (" + hints + ")"
+	} else if hints != "" {
+		comment = "# " + hints
+	}
+	if comment == "" {
+		return line
+	}
+	return line + " " + comment
+}
+
+// Return the hints of what the rule expects, if any.
+func schemaHints(rule *schemaRule) string {
+	if rule == nil {
+		return ""
+	}
+	var hints []string
+	if rule.Required {
+		hints = append(hints, "required")
+	}
+	if len(rule.Enum) > 0 {
+		hints = append(hints, "one of "+strings.Join(rule.Enum, ", "))
+	} else if rule.Type != "string" {
+		hints = append(hints, rule.Type)
+	}
+	if rule.Pattern != nil {
+		hints = append(hints, "matching "+rule.Pattern.String())
+	}
+	return strings.Join(hints, ", ")
+}
+
+// Return the example of the document: its comments and variables in the same
+// order, without the values.
+func templateDocument(doc *envfile.Document, schema *envSchema) *envfile.Document {
+	example := &envfile.Document{TrailingNewline: true, CRLF: doc.CRLF}
+	seen := map[string]bool{}
+	for _, node := range doc.Nodes {
+		if node.Kind == envfile.EntryNode {
+			if seen[node.Entry.Key] {
+				continue // Only the first definition is listed
+			}
+			seen[node.Entry.Key] = true
+			entry := envfile.Entry{Key: node.Entry.Key, Export: node.Entry.Export, Comment: node.Entry.Comment}
+			node = envfile.Node{Kind: envfile.EntryNode, Raw: []string{templateLine(entry, schema.rule(entry.Key))}, Entry: entry}
+			if doc.CRLF {
+				node.Raw[0] += "\r"
+			}
+		}
+		example.Nodes = append(example.Nodes, node)
+	}
+	return example
+}
+
+func template(command []string) string {
+	command[1] = addExtension(command[1])
+
+	output := filepath.Join(filepath.Dir(command[1]), ".env.example")
+	if index, maxIndex, found := isCommand(command, "--output"); found {
+		if index != maxIndex {
+			status = exitUsage
+			return Red + "Expected one file name after --output!\n-help -template for more info!" + Reset
+		}
+		output = command[index]
+	}
+
+	doc, err := envfile.Load(command[1])
+	if err != nil {
+		status = exitNotFound
+		return Red + "Error: File " + command[1] + " doesn't exist or cannot be read!" + Reset
+	}
+
+	// Compare the variables of the example with those of the file
+	if _, _, check := isCommand(command, "--check"); check {
+		example, err := envfile.Load(output)
+		if err != nil {
+			status = exitNotFound
+			return Red + "Error: File " + output + " doesn't exist or cannot be read!" + Reset
+		}
+
+		result := ""
+		var keys, exampleKeys []string
+		for _, entry := range envfile.Effective(doc.Entries()) {
+			keys = append(keys, entry.Key)
+		}
+		for _, entry := range envfile.Effective(example.Entries()) {
+			exampleKeys = append(exampleKeys, entry.Key)
+			if !slices.Contains(keys, entry.Key) {
+				result += Red + "- " + entry.Key + " is in " + output + " but not in " + command[1] + Reset + "\n"
+			}
+		}
+		for _, key := range keys {
+			if !slices.Contains(exampleKeys, key) {
+				result += Red + "+ " + key + " is in " + command[1] + " but not in " + output + Reset + "\n"
+			}
+		}
+		if result != "" {
+			status = exitInvalid
+			return result + Red + output + " is out of date (-template " + command[1] + " to update it)!" + Reset
+		}
+		return Green + output + " lists the variables of " + command[1] + "." + Reset
+	}
+
+	schema, err := schemaFor(command[1])
+	if err != nil {
+		status = exitError
+		return Red + "Error: " + err.Error() + Reset
+	}
+	if written := overwriteFile(output, templateDocument(doc, schema).String()); !written {
+		status = exitError
+		return Red + "Error when writing " + output + "!" + Reset
+	}
+	return Green + output + " has been written with the variables of " + command[1] + " (without their values)." + Reset
+}
@@ -0,0 +1,277 @@
+// Reading of YAML configuration files (block mappings, sequences and scalars).
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Xanoor/EnvCLI/envfile"
+)
+
+// A significant line of a YAML file.
+type yamlLine struct {
+	indent int
+	text   string
+	number int
+}
+
+// Read a YAML mapping, nested keys and sequences being flattened.
+func parseYAMLConfig(content string) ([]envfile.Entry, error) {
+	var lines []yamlLine
+	for i, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		text := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tabs can't be used for indentation", i+1)
+		}
+		text = strings.TrimRight(text, " \t") // Trailing spaces are never significant
+		if text == "---" || text == "..." {
+			continue
+		}
+		lines = append(lines, yamlLine{indent: len(line) - len(strings.TrimLeft(line, " ")), text: text, number: i + 1})
+	}
+
+	var entries []envfile.Entry
+	i := nextYAMLLine(lines, 0)
+	if i >= len(lines) {
+		return nil, nil
+	}
+	i, err := parseYAMLBlock(lines, i, lines[i].indent, "", &entries)
+	if err != nil {
+		return nil, err
+	} else if i < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[i].number)
+	}
+	return entries, nil
+}
+
+// Return the index of the next line with content, comments excepted.
+func nextYAMLLine(lines []yamlLine, i int) int {
+	for i < len(lines) && (lines[i].text == "" || strings.HasPrefix(lines[i].text, "#")) {
+		i++
+	}
+	return i
+}
+
+// Parse the mapping or sequence whose items start at the given indentation.
+// Return the index of the first line after the block.
+func parseYAMLBlock(lines []yamlLine, i int, indent int, prefix string, entries *[]envfile.Entry) (int, error) {
+	item := 0
+	i = nextYAMLLine(lines, i)
+	sequence := i < len(lines) && isYAMLItem(lines[i].text)
+	for ; i < len(lines); i = nextYAMLLine(lines, i) {
+		line := lines[i]
+		if line.indent < indent {
+			return i, nil
+		} else if line.indent > indent {
+			return i, fmt.Errorf("line %d: unexpected indentation", line.number)
+		} else if isYAMLItem(line.text) != sequence {
+			if sequence {
+				return i, nil // End of a sequence written at the indentation of its key
+			}
+			return i, fmt.Errorf("line %d: unexpected sequence item", line.number)
+		}
+
+		// Sequence item
+		if sequence {
+			key := joinKey(prefix, strconv.Itoa(item))
+			item++
+			rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+			if rest == "" || strings.HasPrefix(rest, "#") {
+				// The item is the block of the next lines
+				var err error
+				if i, err = parseYAMLChild(lines, i+1, indent, key, entries); err != nil {
+					return i, err
+				}
+				continue
+			}
+			if _, _, isMapping := splitYAMLKey(rest); isMapping {
+				// "- key: value" starts a mapping indented after the dash
+				lines[i] = yamlLine{indent: indent + len(line.text) - len(rest), text: rest, number: line.number}
+				var err error
+				if i, err = parseYAMLBlock(lines, i, lines[i].indent, key, entries); err != nil {
+					return i, err
+				}
+				continue
+			}
+			value, next, err := yamlScalar(lines, i, rest)
+			if err != nil {
+				return i, err
+			}
+			*entries = append(*entries, envfile.Entry{Key: key, Value: value})
+			i = next
+			continue
+		}
+
+		// Mapping item
+		name, rest, isMapping := splitYAMLKey(line.text)
+		if !isMapping {
+			return i, fmt.Errorf("line %d: expected \"key: value\"", line.number)
+		}
+		key := joinKey(prefix, name)
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			var err error
+			if i, err = parseYAMLChild(lines, i+1, indent, key, entries); err != nil {
+				return i, err
+			}
+			continue
+		}
+		value, next, err := yamlScalar(lines, i, rest)
+		if err != nil {
+			return i, err
+		}
+		*entries = append(*entries, envfile.Entry{Key: key, Value: value})
+		i = next
+	}
+	return i, nil
+}
+
+// Parse the block nested under a key without value. A key followed by nothing is empty.
+func parseYAMLChild(lines []yamlLine, i int, indent int, key string, entries *[]envfile.Entry) (int, error) {
+	next := nextYAMLLine(lines, i)
+	if next < len(lines) {
+		child := lines[next]
+		// Sequences may be at the indentation of their key
+		if child.indent > indent || (child.indent == indent && isYAMLItem(child.text)) {
+			return parseYAMLBlock(lines, next, child.indent, key, entries)
+		}
+	}
+	*entries = append(*entries, envfile.Entry{Key: key})
+	return next, nil
+}
+
+// Check if the line is a sequence item.
+func isYAMLItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// Split a "key: value" line. Return false if the line isn't a mapping item.
+func splitYAMLKey(text string) (string, string, bool) {
+	if text[0] == '"' || text[0] == '\'' {
+		value, rest, err := yamlQuoted(text)
+		if err != nil || !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		return value, strings.TrimLeft(rest[1:], " "), true
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimLeft(text[i+1:], " "), true
+		} else if text[i] == '#' && i > 0 && text[i-1] == ' ' {
+			break
+		}
+	}
+	return "", "", false
+}
+
+// Read the scalar value starting with text on lines[i], which may be a block
+// scalar (| or >) continuing on the next lines. Return the index of the next line.
+func yamlScalar(lines []yamlLine, i int, text string) (string, int, error) {
+	line := lines[i]
+	switch text[0] {
+	case '"', '\'':
+		value, rest, err := yamlQuoted(text)
+		if err != nil {
+			return "", i, fmt.Errorf("line %d: %v", line.number, err)
+		} else if rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", i, fmt.Errorf("line %d: unexpected characters after quoted value", line.number)
+		}
+		return value, i + 1, nil
+	case '|', '>':
+		return yamlBlockScalar(lines, i, text)
+	case '&', '*', '!':
+		return "", i, fmt.Errorf("line %d: anchors, aliases and tags are not supported", line.number)
+	case '{', '[':
+		if text == "{}" || text == "[]" {
+			return "", i + 1, nil
+		}
+		return "", i, fmt.Errorf("line %d: flow collections are not supported", line.number)
+	}
+
+	// Plain scalar, up to its comment
+	if index := strings.Index(text, " #"); index >= 0 {
+		text = strings.TrimSpace(text[:index])
+	}
+	if text == "~" || text == "null" {
+		text = ""
+	}
+	return text, i + 1, nil
+}
+
+// Read a block scalar: "|" keeps the line breaks, ">" folds lines into spaces.
+// The value ends with one line break, none with "-" and all of them with "+".
+func yamlBlockScalar(lines []yamlLine, i int, header string) (string, int, error) {
+	parent := lines[i].indent
+
+	var content []string
+	indent := -1
+	j := i + 1
+	for ; j < len(lines); j++ {
+		line := lines[j]
+		if line.text == "" {
+			content = append(content, "")
+			continue
+		}
+		if line.indent <= parent {
+			break
+		}
+		if indent < 0 {
+			indent = line.indent
+		}
+		content = append(content, strings.Repeat(" ", max(line.indent-indent, 0))+line.text)
+	}
+
+	// Trailing empty lines belong to the following block, unless they are kept
+	trailing := 0
+	for len(content) > 0 && content[len(content)-1] == "" {
+		content = content[:len(content)-1]
+		trailing++
+		j--
+	}
+
+	separator := "\n"
+	if strings.HasPrefix(header, ">") {
+		separator = " "
+	}
+	value := strings.Join(content, separator)
+	switch {
+	case strings.Contains(header, "-"): // Strip
+	case strings.Contains(header, "+"): // Keep
+		value += strings.Repeat("\n", trailing+1)
+	case value != "": // Clip
+		value += "\n"
+	}
+	return value, j, nil
+}
+
+// Read the quoted string at the start of text. Return its value and the rest of text.
+func yamlQuoted(text string) (string, string, error) {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		if quote == '"' && text[i] == '\\' {
+			i++
+			continue
+		}
+		if text[i] != quote {
+			continue
+		}
+		// '' is an escaped quote inside single quotes
+		if quote == '\'' && i+1 < len(text) && text[i+1] == '\'' {
+			i++
+			continue
+		}
+
+		rest := strings.TrimSpace(text[i+1:])
+		if quote == '\'' {
+			return strings.ReplaceAll(text[1:i], "''", "'"), rest, nil
+		}
+		var value string
+		if err := json.Unmarshal([]byte(text[:i+1]), &value); err != nil {
+			return "", "", fmt.Errorf("invalid string %s", text[:i+1])
+		}
+		return value, rest, nil
+	}
+	return "", "", fmt.Errorf("unterminated string %s", text)
+}